@@ -1,27 +1,92 @@
 package main
 
 import (
-	"log"
+	"context"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"go-nelson/pkg"
 	"go-nelson/pkg/db"
+	"go-nelson/pkg/logger"
 	"go-nelson/pkg/news"
 	"go-nelson/pkg/services"
+	"go-nelson/pkg/utils"
+	"go-nelson/pkg/web"
 )
 
 func main() {
 	err := pkg.LoadConfig("configs.json")
 	if err != nil {
-		log.Fatalf("Failed to load config: %v", err)
+		fatal("Failed to load config", err)
+	}
+
+	err = logger.Configure(pkg.Logging)
+	if err != nil {
+		fatal("Invalid logging config", err)
+	}
+
+	err = utils.ConfigureFetcher(pkg.HTTP)
+	if err != nil {
+		fatal("Invalid HTTP config", err)
+	}
+
+	// Источники из настроек регистрируются до маршрутизации, чтобы маршруты могли ссылаться на них по имени
+	news.RegisterConfiguredSources()
+
+	err = services.ConfigureRouting()
+	if err != nil {
+		fatal("Invalid routing config", err)
 	}
 
 	err = db.Initialize(pkg.MongoDB.URI, pkg.MongoDB.Database)
 	if err != nil {
-		log.Fatalf("Failed to initialize database: %v", err)
+		fatal("Failed to initialize database", err)
 	}
-	defer db.Close()
 
-	services.Start()
+	utils.SetValidatorStore(db.NewHTTPCacheRepository())
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	services.Start(ctx)
+
+	web.Start()
+
+	code := 0
+	if err := news.StartNewsParser(ctx); err != nil {
+		slog.Error("News parser failed", "error", err)
+		code = 1
+	}
+
+	// Повторный сигнал во время остановки завершает процесс сразу
+	stop()
+	os.Exit(shutdown(code))
+}
+
+// Код 0 означает, что все начатые отправки завершились и соединения закрыты,
+// 1 - что часть работы прервана или остановка прошла с ошибками
+func shutdown(code int) int {
+	slog.Info("Shutting down")
+
+	web.Close()
+
+	if err := services.Close(); err != nil {
+		slog.Error("Services did not stop cleanly", "error", err)
+		code = 1
+	}
+
+	if err := db.Close(); err != nil {
+		code = 1
+	}
+
+	slog.Info("Shutdown complete", "exit_code", code)
+	return code
+}
 
-	news.StartNewsParser()
+// os.Exit не выполняет отложенные вызовы, поэтому используется только до открытия соединений
+func fatal(message string, err error) {
+	slog.Error(message, "error", err)
+	os.Exit(1)
 }
@@ -11,7 +11,16 @@ var Discord structures.DiscordConfigStruct
 var Telegram structures.TelegramConfigStruct
 var MongoDB structures.MongoDBConfigStruct
 var GoogleAistudio structures.GoogleAistudioConfigStruct
+var Delivery structures.DeliveryConfigStruct
+var HTTP structures.HTTPConfigStruct
+var Routing structures.RoutingConfigStruct
+var Watchlists structures.WatchlistConfigStruct
+var Server structures.ServerConfigStruct
+var Logging structures.LoggingConfigStruct
+var Scheduler structures.SchedulerConfigStruct
 var Parsers structures.ParsersConfigStruct
+var Feeds []structures.FeedConfigStruct
+var Scrapers []structures.ScraperConfigStruct
 
 func LoadConfig(filename string) error {
 	if filename == "" {
@@ -36,7 +45,16 @@ func LoadConfig(filename string) error {
 	Telegram = config.Telegram
 	MongoDB = config.MongoDB
 	GoogleAistudio = config.GoogleAistudio
+	Delivery = config.Delivery
+	HTTP = config.HTTP
+	Routing = config.Routing
+	Watchlists = config.Watchlists
+	Server = config.Server
+	Logging = config.Logging
+	Scheduler = config.Scheduler
 	Parsers = config.Parsers
+	Feeds = config.Feeds
+	Scrapers = config.Scrapers
 
 	return nil
 }
@@ -2,17 +2,22 @@ package db
 
 import (
 	"context"
-	"log"
+	"go-nelson/pkg/metrics"
+	"log/slog"
 	"sync"
 	"time"
 
 	"github.com/qiniu/qmgo"
+	opts "github.com/qiniu/qmgo/options"
+	"go.mongodb.org/mongo-driver/event"
+	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
 var (
 	client       *qmgo.Client
 	database     *qmgo.Database
 	collections  map[string]*qmgo.Collection
+	collectionMu sync.RWMutex
 	initializeMu sync.Mutex
 	initialized  bool
 )
@@ -29,7 +34,9 @@ func Initialize(uri, dbName string) error {
 	defer cancel()
 
 	var err error
-	client, err = qmgo.NewClient(ctx, &qmgo.Config{Uri: uri})
+	client, err = qmgo.NewClient(ctx, &qmgo.Config{Uri: uri}, opts.ClientOptions{
+		ClientOptions: options.Client().SetMonitor(commandMonitor()),
+	})
 	if err != nil {
 		return err
 	}
@@ -41,17 +48,40 @@ func Initialize(uri, dbName string) error {
 	return nil
 }
 
+// Длительность учитывается по всем командам драйвера, поэтому отдельные методы репозиториев не размечаются
+func commandMonitor() *event.CommandMonitor {
+	return &event.CommandMonitor{
+		Succeeded: func(_ context.Context, evt *event.CommandSucceededEvent) {
+			metrics.MongoOperationDuration.WithLabelValues(evt.CommandName, "success").Observe(evt.Duration.Seconds())
+		},
+		Failed: func(_ context.Context, evt *event.CommandFailedEvent) {
+			metrics.MongoOperationDuration.WithLabelValues(evt.CommandName, "failure").Observe(evt.Duration.Seconds())
+		},
+	}
+}
+
 func GetCollection(name string) *qmgo.Collection {
 	if !initialized {
-		log.Printf("Warning: Database not initialized when trying to get collection %s", name)
+		slog.Warn("Database not initialized", "collection", name)
 		return nil
 	}
 
+	// Репозитории создаются одновременно из обработчиков очереди, веб-запросов и сбора метрик
+	collectionMu.RLock()
+	col, ok := collections[name]
+	collectionMu.RUnlock()
+	if ok {
+		return col
+	}
+
+	collectionMu.Lock()
+	defer collectionMu.Unlock()
+
 	if col, ok := collections[name]; ok {
 		return col
 	}
 
-	col := database.Collection(name)
+	col = database.Collection(name)
 	collections[name] = col
 	return col
 }
@@ -66,7 +96,7 @@ func Close() error {
 
 	err := client.Close(ctx)
 	if err != nil {
-		log.Printf("Error closing MongoDB connection: %v", err)
+		slog.Error("Failed to close MongoDB connection", "error", err)
 		return err
 	}
 
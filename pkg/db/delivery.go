@@ -0,0 +1,302 @@
+package db
+
+import (
+	"context"
+	"go-nelson/pkg/structures"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/qiniu/qmgo"
+	"github.com/qiniu/qmgo/operator"
+	opts "github.com/qiniu/qmgo/options"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type DeliveryRepository struct {
+	collection *qmgo.Collection
+}
+
+// Репозиторий создается обработчиками очереди, веб-запросами и при каждом сборе метрик,
+// поэтому индексы строятся один раз за время работы процесса
+var deliveryIndexesOnce sync.Once
+
+func NewDeliveryRepository() *DeliveryRepository {
+	coll := GetCollection("deliveries")
+
+	deliveryIndexesOnce.Do(func() {
+		createDeliveryIndexes(coll)
+	})
+
+	return &DeliveryRepository{
+		collection: coll,
+	}
+}
+
+func createDeliveryIndexes(coll *qmgo.Collection) {
+	ctx := context.Background()
+	indexOpt := options.Index().SetUnique(true)
+
+	err := coll.CreateIndexes(ctx, []opts.IndexModel{
+		{
+			Key:          []string{"news_id", "destination"},
+			IndexOptions: indexOpt,
+		},
+		{
+			Key: []string{"destination", "status", "next_retry_at"},
+		},
+	})
+	if err != nil {
+		slog.Error("Failed to create index", "collection", "deliveries", "error", err)
+	}
+}
+
+func (r *DeliveryRepository) Enqueue(newsID primitive.ObjectID, destination string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+
+	return r.collection.UpdateOne(ctx, bson.M{
+		"news_id":     newsID,
+		"destination": destination,
+	}, bson.M{
+		operator.SetOnInsert: bson.M{
+			"_id":           primitive.NewObjectID(),
+			"news_id":       newsID,
+			"destination":   destination,
+			"action":        structures.DeliveryActionPublish,
+			"status":        structures.DeliveryStatusPending,
+			"attempts":      0,
+			"next_retry_at": now,
+			"createAt":      now,
+			"updateAt":      now,
+		},
+	}, opts.UpdateOptions{
+		UpdateOptions: options.Update().SetUpsert(true),
+	})
+}
+
+func (r *DeliveryRepository) RequeueSent(newsID primitive.ObjectID, action string) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+
+	result, err := r.collection.UpdateAll(ctx, bson.M{
+		"news_id": newsID,
+		"status":  structures.DeliveryStatusSent,
+	}, bson.M{
+		operator.Set: bson.M{
+			"action":        action,
+			"status":        structures.DeliveryStatusPending,
+			"attempts":      0,
+			"next_retry_at": now,
+			"updateAt":      now,
+		},
+		operator.Unset: bson.M{
+			"last_error": "",
+		},
+		operator.Inc: bson.M{
+			"requeues": 1,
+		},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return result.ModifiedCount, nil
+}
+
+func (r *DeliveryRepository) CancelPending(newsID primitive.ObjectID, reason string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := r.collection.UpdateAll(ctx, bson.M{
+		"news_id": newsID,
+		"status":  structures.DeliveryStatusPending,
+		"action": bson.M{
+			operator.In: []string{structures.DeliveryActionPublish, structures.DeliveryActionEdit},
+		},
+	}, bson.M{
+		operator.Set: bson.M{
+			"status":     structures.DeliveryStatusFailed,
+			"last_error": reason,
+			"updateAt":   time.Now(),
+		},
+	})
+
+	return err
+}
+
+func (r *DeliveryRepository) FindDue(destination string, limit int64) ([]*structures.Delivery, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result := make([]*structures.Delivery, 0)
+
+	err := r.collection.Find(ctx, bson.M{
+		"destination": destination,
+		"status":      structures.DeliveryStatusPending,
+		"next_retry_at": bson.M{
+			operator.Lte: time.Now(),
+		},
+	}).Sort("next_retry_at").Limit(limit).All(&result)
+
+	return result, err
+}
+
+// partMessageIDs равен nil, если отправитель не отправлял продолжений, и тогда сохраненные ID не меняются
+func (r *DeliveryRepository) MarkSent(id primitive.ObjectID, messageID string, partMessageIDs []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+
+	set := bson.M{
+		"status":   structures.DeliveryStatusSent,
+		"sent_at":  now,
+		"updateAt": now,
+	}
+	if messageID != "" {
+		set["message_id"] = messageID
+	}
+	if partMessageIDs != nil {
+		set["part_message_ids"] = partMessageIDs
+	}
+
+	return r.collection.UpdateId(ctx, id, bson.M{
+		operator.Set: set,
+		operator.Inc: bson.M{
+			"attempts": 1,
+		},
+		operator.Unset: bson.M{
+			"last_error": "",
+		},
+	})
+}
+
+func (r *DeliveryRepository) MarkRetry(id primitive.ObjectID, lastError string, nextRetryAt time.Time) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return r.collection.UpdateId(ctx, id, bson.M{
+		operator.Set: bson.M{
+			"status":        structures.DeliveryStatusPending,
+			"last_error":    lastError,
+			"next_retry_at": nextRetryAt,
+			"updateAt":      time.Now(),
+		},
+		operator.Inc: bson.M{
+			"attempts": 1,
+		},
+	})
+}
+
+func (r *DeliveryRepository) MarkFailed(id primitive.ObjectID, lastError string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return r.collection.UpdateId(ctx, id, bson.M{
+		operator.Set: bson.M{
+			"status":     structures.DeliveryStatusFailed,
+			"last_error": lastError,
+			"updateAt":   time.Now(),
+		},
+		operator.Inc: bson.M{
+			"attempts": 1,
+		},
+	})
+}
+
+func (r *DeliveryRepository) CountByStatus(destination, status string) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return r.collection.Find(ctx, bson.M{
+		"destination": destination,
+		"status":      status,
+	}).Count()
+}
+
+func (r *DeliveryRepository) FindByStatus(status string, limit int64) ([]*structures.Delivery, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result := make([]*structures.Delivery, 0)
+	err := r.collection.Find(ctx, bson.M{"status": status}).
+		Sort("-updateAt").
+		Limit(limit).
+		All(&result)
+
+	return result, err
+}
+
+// FindWithMessages возвращает доставки новостей в указанные назначения, по которым уже есть отправленное сообщение
+func (r *DeliveryRepository) FindWithMessages(newsIDs []primitive.ObjectID, destinations []string) ([]*structures.Delivery, error) {
+	result := make([]*structures.Delivery, 0)
+	if len(newsIDs) == 0 || len(destinations) == 0 {
+		return result, nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := r.collection.Find(ctx, bson.M{
+		"news_id":     bson.M{operator.In: newsIDs},
+		"destination": bson.M{operator.In: destinations},
+		"message_id":  bson.M{operator.Exists: true, operator.Ne: ""},
+		"action":      bson.M{operator.Ne: structures.DeliveryActionDelete},
+	}).All(&result)
+
+	return result, err
+}
+
+// Retry возвращает неудавшуюся доставку в очередь с обнуленным счетчиком попыток
+func (r *DeliveryRepository) Retry(id primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+
+	return r.collection.UpdateOne(ctx, bson.M{
+		"_id":    id,
+		"status": structures.DeliveryStatusFailed,
+	}, bson.M{
+		operator.Set: bson.M{
+			"status":        structures.DeliveryStatusPending,
+			"attempts":      0,
+			"next_retry_at": now,
+			"updateAt":      now,
+		},
+		operator.Unset: bson.M{
+			"last_error": "",
+		},
+	})
+}
+
+// QueueStats группирует доставки по назначению и статусу, Oldest — время самой старой записи группы
+func (r *DeliveryRepository) QueueStats() ([]structures.DeliveryQueueStats, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result := make([]structures.DeliveryQueueStats, 0)
+
+	err := r.collection.Aggregate(ctx, []bson.M{
+		{operator.Match: bson.M{
+			"status": bson.M{operator.In: []string{structures.DeliveryStatusPending, structures.DeliveryStatusFailed}},
+		}},
+		{operator.Group: bson.M{
+			"_id": bson.M{
+				"destination": "$destination",
+				"status":      "$status",
+			},
+			"count":  bson.M{operator.Sum: 1},
+			"oldest": bson.M{operator.Min: "$updateAt"},
+		}},
+	}).All(&result)
+
+	return result, err
+}
@@ -0,0 +1,71 @@
+package db
+
+import (
+	"context"
+	"go-nelson/pkg/structures"
+	"log/slog"
+	"time"
+
+	"github.com/qiniu/qmgo"
+	"github.com/qiniu/qmgo/operator"
+	opts "github.com/qiniu/qmgo/options"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type HTTPCacheRepository struct {
+	collection *qmgo.Collection
+}
+
+func NewHTTPCacheRepository() *HTTPCacheRepository {
+	coll := GetCollection("http_cache")
+
+	ctx := context.Background()
+	indexOpt := options.Index().SetUnique(true)
+
+	err := coll.CreateOneIndex(ctx, opts.IndexModel{
+		Key:          []string{"url"},
+		IndexOptions: indexOpt,
+	})
+	if err != nil {
+		slog.Error("Failed to create index", "collection", "http_cache", "error", err)
+	}
+
+	return &HTTPCacheRepository{
+		collection: coll,
+	}
+}
+
+func (r *HTTPCacheRepository) FindByURL(url string) (*structures.HTTPCache, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	cache := &structures.HTTPCache{}
+	err := r.collection.Find(ctx, bson.M{"url": url}).One(cache)
+	if qmgo.IsErrNoDocuments(err) {
+		return nil, nil
+	}
+
+	return cache, err
+}
+
+func (r *HTTPCacheRepository) Save(cache *structures.HTTPCache) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+
+	return r.collection.UpdateOne(ctx, bson.M{"url": cache.URL}, bson.M{
+		operator.Set: bson.M{
+			"url":           cache.URL,
+			"etag":          cache.ETag,
+			"last_modified": cache.LastModified,
+			"updateAt":      now,
+		},
+		operator.SetOnInsert: bson.M{
+			"createAt": now,
+		},
+	}, opts.UpdateOptions{
+		UpdateOptions: options.Update().SetUpsert(true),
+	})
+}
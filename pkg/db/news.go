@@ -3,7 +3,9 @@ package db
 import (
 	"context"
 	"go-nelson/pkg/structures"
-	"log"
+	"log/slog"
+	"regexp"
+	"sync"
 	"time"
 
 	"github.com/qiniu/qmgo"
@@ -11,6 +13,7 @@ import (
 	opts "github.com/qiniu/qmgo/options"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 )
 
@@ -18,9 +21,38 @@ type NewsRepository struct {
 	collection *qmgo.Collection
 }
 
+type NewsFilter struct {
+	Provider     string
+	Tag          string
+	Query        string
+	Text         string
+	From         time.Time
+	To           time.Time
+	ActiveOffers bool
+}
+
+// Позиция последней выданной новости для постраничного чтения без пропусков при появлении новых записей
+type NewsCursor struct {
+	CreateAt time.Time
+	ID       primitive.ObjectID
+}
+
+// Репозиторий создается на каждый запрос, поэтому индексы строятся один раз за время работы процесса
+var newsIndexesOnce sync.Once
+
 func NewNewsRepository() *NewsRepository {
 	coll := GetCollection("news")
 
+	newsIndexesOnce.Do(func() {
+		createNewsIndexes(coll)
+	})
+
+	return &NewsRepository{
+		collection: coll,
+	}
+}
+
+func createNewsIndexes(coll *qmgo.Collection) {
 	ctx := context.Background()
 	indexOpt := options.Index().SetUnique(true)
 
@@ -29,11 +61,20 @@ func NewNewsRepository() *NewsRepository {
 		IndexOptions: indexOpt,
 	})
 	if err != nil {
-		log.Printf("Error creating index on news collection: %v", err)
+		slog.Error("Failed to create index", "collection", "news", "error", err)
 	}
 
-	return &NewsRepository{
-		collection: coll,
+	// qmgo не умеет создавать текстовые индексы, поэтому используется драйвер напрямую.
+	// Новости бывают на разных языках, поэтому стемминг отключен
+	rawColl, err := coll.CloneCollection()
+	if err == nil {
+		_, err = rawColl.Indexes().CreateOne(ctx, mongo.IndexModel{
+			Keys:    bson.D{{Key: "title", Value: "text"}, {Key: "description", Value: "text"}},
+			Options: options.Index().SetName("news_text").SetDefaultLanguage("none").SetLanguageOverride("text_language"),
+		})
+	}
+	if err != nil {
+		slog.Error("Failed to create text index", "collection", "news", "error", err)
 	}
 }
 
@@ -51,6 +92,7 @@ func (r *NewsRepository) Save(news *structures.News) error {
 
 	if err == nil {
 		news.Id = existingNews.Id
+		news.CreateAt = existingNews.CreateAt
 		news.UpdateAt = time.Now()
 
 		err = r.collection.UpdateOne(ctx, filter, bson.M{
@@ -84,6 +126,21 @@ func (r *NewsRepository) FindByID(id string) (*structures.News, error) {
 	return news, err
 }
 
+func (r *NewsRepository) FindByIDs(ids []primitive.ObjectID) ([]*structures.News, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result := make([]*structures.News, 0)
+
+	err := r.collection.Find(ctx, bson.M{
+		"_id": bson.M{
+			operator.In: ids,
+		},
+	}).Sort("createAt").All(&result)
+
+	return result, err
+}
+
 func (r *NewsRepository) FindByProviderAndUniqueID(provider, uniqueID string) (*structures.News, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -110,7 +167,7 @@ func (r *NewsRepository) UpdateDiscordInfo(newsID, threadID, messageID string) e
 		operator.Set: bson.M{
 			"discord_thread_id":  threadID,
 			"discord_message_id": messageID,
-			"updateat":           time.Now(),
+			"updateAt":           time.Now(),
 		},
 	}
 
@@ -129,21 +186,21 @@ func (r *NewsRepository) UpdateTelegramInfo(newsID, messageID string) error {
 	update := bson.M{
 		operator.Set: bson.M{
 			"telegram_message_id": messageID,
-			"updateat":            time.Now(),
+			"updateAt":            time.Now(),
 		},
 	}
 
 	return r.collection.UpdateOne(ctx, bson.M{"_id": objID}, update)
 }
 
-func (r *NewsRepository) FindRecent(page, limit int64) ([]*structures.News, error) {
+func (r *NewsRepository) FindRecent(filter NewsFilter, page, limit int64) ([]*structures.News, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	result := make([]*structures.News, 0)
 
-	err := r.collection.Find(ctx, bson.M{}).
-		Sort("-createat").
+	err := r.collection.Find(ctx, filter.bson()).
+		Sort("-createAt").
 		Skip(page * limit).
 		Limit(limit).
 		All(&result)
@@ -173,3 +230,261 @@ func (r *NewsRepository) FindNewsByProviderAndUniqueIDs(provider string, uniqueI
 
 	return result, err
 }
+
+func (r *NewsRepository) FindByProviderAndUniqueIDs(provider string, uniqueIDs []string) (map[string]*structures.News, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result := make(map[string]*structures.News)
+
+	var foundNews []*structures.News
+	err := r.collection.Find(ctx, bson.M{
+		"provider": provider,
+		"unique_id": bson.M{
+			operator.In: uniqueIDs,
+		},
+	}).All(&foundNews)
+
+	for _, news := range foundNews {
+		result[news.UniqueID] = news
+	}
+
+	return result, err
+}
+
+func (r *NewsRepository) FindActiveSince(provider string, since time.Time) ([]*structures.News, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result := make([]*structures.News, 0)
+
+	err := r.collection.Find(ctx, bson.M{
+		"provider": provider,
+		"createAt": bson.M{
+			operator.Gte: since,
+		},
+		"retracted_at": bson.M{
+			operator.Exists: false,
+		},
+	}).All(&result)
+
+	return result, err
+}
+
+// FindDeliveryPending возвращает новости, сохраненные без постановки в очередь доставки,
+// например из-за остановки процесса между сохранением и постановкой
+func (r *NewsRepository) FindDeliveryPending() ([]*structures.News, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result := make([]*structures.News, 0)
+
+	err := r.collection.Find(ctx, bson.M{
+		"delivery_pending": true,
+		"retracted_at": bson.M{
+			operator.Exists: false,
+		},
+		"hidden_at": bson.M{
+			operator.Exists: false,
+		},
+	}).Sort("createAt").All(&result)
+
+	return result, err
+}
+
+func (r *NewsRepository) ClearDeliveryPending(id primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return r.collection.UpdateId(ctx, id, bson.M{
+		operator.Unset: bson.M{"delivery_pending": ""},
+	})
+}
+
+// IncrementMissingRuns возвращает число подряд идущих успешных запусков, в которых новости не было в выдаче
+func (r *NewsRepository) IncrementMissingRuns(id primitive.ObjectID) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	news := &structures.News{}
+	err := r.collection.Find(ctx, bson.M{"_id": id}).Apply(qmgo.Change{
+		Update:    bson.M{operator.Inc: bson.M{"missing_runs": 1}},
+		ReturnNew: true,
+	}, news)
+
+	return news.MissingRuns, err
+}
+
+func (r *NewsRepository) ResetMissingRuns(ids []primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := r.collection.UpdateAll(ctx, bson.M{
+		"_id": bson.M{operator.In: ids},
+	}, bson.M{
+		operator.Unset: bson.M{"missing_runs": ""},
+	})
+
+	return err
+}
+
+func (r *NewsRepository) MarkRetracted(id primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+
+	return r.collection.UpdateId(ctx, id, bson.M{
+		operator.Set: bson.M{
+			"retracted_at": now,
+			"updateAt":     now,
+		},
+	})
+}
+
+func (f NewsFilter) bson() bson.M {
+	filter := bson.M{
+		"retracted_at": bson.M{
+			operator.Exists: false,
+		},
+		"hidden_at": bson.M{
+			operator.Exists: false,
+		},
+	}
+
+	if f.Provider != "" {
+		filter["provider"] = f.Provider
+	}
+
+	if f.Tag != "" {
+		filter["tags"] = primitive.Regex{Pattern: "^" + regexp.QuoteMeta(f.Tag) + "$", Options: "i"}
+	}
+
+	if f.Query != "" {
+		pattern := primitive.Regex{Pattern: regexp.QuoteMeta(f.Query), Options: "i"}
+		filter[operator.Or] = []bson.M{
+			{"title": pattern},
+			{"description": pattern},
+		}
+	}
+
+	if f.Text != "" {
+		filter["$text"] = bson.M{"$search": f.Text}
+	}
+
+	if !f.From.IsZero() || !f.To.IsZero() {
+		createAt := bson.M{}
+		if !f.From.IsZero() {
+			createAt[operator.Gte] = f.From
+		}
+		if !f.To.IsZero() {
+			createAt[operator.Lt] = f.To
+		}
+		filter["createAt"] = createAt
+	}
+
+	if f.ActiveOffers {
+		now := time.Now()
+		filter["expires_at"] = bson.M{operator.Gt: now}
+		filter["published_at"] = bson.M{operator.Lte: now}
+	}
+
+	return filter
+}
+
+func (r *NewsRepository) Find(filter NewsFilter, skip, limit int64) ([]*structures.News, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result := make([]*structures.News, 0)
+
+	err := r.collection.Find(ctx, filter.bson()).
+		Sort("-createAt").
+		Skip(skip).
+		Limit(limit).
+		All(&result)
+
+	return result, err
+}
+
+func (r *NewsRepository) FindAfter(filter NewsFilter, cursor *NewsCursor, limit int64) ([]*structures.News, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	query := filter.bson()
+	if cursor != nil {
+		query = bson.M{operator.And: []bson.M{query, {
+			operator.Or: []bson.M{
+				{"createAt": bson.M{operator.Lt: cursor.CreateAt}},
+				{"createAt": cursor.CreateAt, "_id": bson.M{operator.Lt: cursor.ID}},
+			},
+		}}}
+	}
+
+	result := make([]*structures.News, 0)
+
+	err := r.collection.Find(ctx, query).
+		Sort("-createAt", "-_id").
+		Limit(limit).
+		All(&result)
+
+	return result, err
+}
+
+// Search ищет по текстовому индексу и сортирует по релевантности, поле Text фильтра обязательно
+func (r *NewsRepository) Search(filter NewsFilter, skip, limit int64) ([]*structures.News, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result := make([]*structures.News, 0)
+
+	err := r.collection.Aggregate(ctx, []bson.M{
+		{operator.Match: filter.bson()},
+		{operator.AddFields: bson.M{"score": bson.M{"$meta": "textScore"}}},
+		{operator.Sort: bson.D{{Key: "score", Value: -1}, {Key: "createAt", Value: -1}}},
+		{operator.Skip: skip},
+		{operator.Limit: limit},
+	}).All(&result)
+
+	return result, err
+}
+
+func (r *NewsRepository) Count(filter NewsFilter) (int64, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return r.collection.Find(ctx, filter.bson()).Count()
+}
+
+func (r *NewsRepository) ProviderStats() ([]structures.ProviderStats, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result := make([]structures.ProviderStats, 0)
+
+	err := r.collection.Aggregate(ctx, []bson.M{
+		{operator.Match: NewsFilter{}.bson()},
+		{operator.Group: bson.M{
+			"_id":     "$provider",
+			"count":   bson.M{operator.Sum: 1},
+			"last_at": bson.M{operator.Max: "$createAt"},
+		}},
+		{operator.Sort: bson.M{"_id": 1}},
+	}).All(&result)
+
+	return result, err
+}
+
+func (r *NewsRepository) Hide(id primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+
+	return r.collection.UpdateId(ctx, id, bson.M{
+		operator.Set: bson.M{
+			"hidden_at": now,
+			"updateAt":  now,
+		},
+	})
+}
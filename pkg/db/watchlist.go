@@ -0,0 +1,210 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"go-nelson/pkg/structures"
+	"log/slog"
+	"time"
+
+	"github.com/qiniu/qmgo"
+	"github.com/qiniu/qmgo/operator"
+	opts "github.com/qiniu/qmgo/options"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type WatchlistRepository struct {
+	collection *qmgo.Collection
+}
+
+func NewWatchlistRepository() *WatchlistRepository {
+	coll := GetCollection("watchlists")
+
+	ctx := context.Background()
+	indexOpt := options.Index().SetUnique(true)
+
+	err := coll.CreateOneIndex(ctx, opts.IndexModel{
+		Key:          []string{"platform", "user_id"},
+		IndexOptions: indexOpt,
+	})
+	if err != nil {
+		slog.Error("Failed to create index", "collection", "watchlists", "error", err)
+	}
+
+	return &WatchlistRepository{
+		collection: coll,
+	}
+}
+
+func watchRuleField(kind string) (string, error) {
+	switch kind {
+	case structures.WatchRuleKeyword:
+		return "keywords", nil
+	case structures.WatchRuleRegex:
+		return "regexes", nil
+	case structures.WatchRuleProvider:
+		return "providers", nil
+	}
+	return "", fmt.Errorf("неизвестный тип правила %s", kind)
+}
+
+func (r *WatchlistRepository) FindByUser(platform, userID string) (*structures.Watchlist, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	watchlist := &structures.Watchlist{}
+	err := r.collection.Find(ctx, bson.M{"platform": platform, "user_id": userID}).One(watchlist)
+	if qmgo.IsErrNoDocuments(err) {
+		return nil, nil
+	}
+
+	return watchlist, err
+}
+
+func (r *WatchlistRepository) FindAll() ([]*structures.Watchlist, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result := make([]*structures.Watchlist, 0)
+	err := r.collection.Find(ctx, bson.M{}).All(&result)
+
+	return result, err
+}
+
+func (r *WatchlistRepository) FindWithPending() ([]*structures.Watchlist, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	result := make([]*structures.Watchlist, 0)
+	err := r.collection.Find(ctx, bson.M{
+		"pending.0": bson.M{operator.Exists: true},
+	}).All(&result)
+
+	return result, err
+}
+
+func (r *WatchlistRepository) AddRule(platform, userID, kind, value string) error {
+	field, err := watchRuleField(kind)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+
+	return r.collection.UpdateOne(ctx, bson.M{"platform": platform, "user_id": userID}, bson.M{
+		operator.AddToSet: bson.M{
+			field: value,
+		},
+		operator.Set: bson.M{
+			"updateAt": now,
+		},
+		operator.SetOnInsert: bson.M{
+			"createAt":     now,
+			"digest":       false,
+			"window_count": 0,
+		},
+	}, opts.UpdateOptions{
+		UpdateOptions: options.Update().SetUpsert(true),
+	})
+}
+
+func (r *WatchlistRepository) RemoveRule(platform, userID, kind, value string) error {
+	field, err := watchRuleField(kind)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err = r.collection.UpdateOne(ctx, bson.M{"platform": platform, "user_id": userID}, bson.M{
+		operator.Pull: bson.M{
+			field: value,
+		},
+		operator.Set: bson.M{
+			"updateAt": time.Now(),
+		},
+	})
+	if qmgo.IsErrNoDocuments(err) {
+		return nil
+	}
+
+	return err
+}
+
+func (r *WatchlistRepository) SetDigest(platform, userID string, digest bool) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+
+	return r.collection.UpdateOne(ctx, bson.M{"platform": platform, "user_id": userID}, bson.M{
+		operator.Set: bson.M{
+			"digest":   digest,
+			"updateAt": now,
+		},
+		operator.SetOnInsert: bson.M{
+			"createAt":     now,
+			"window_count": 0,
+		},
+	}, opts.UpdateOptions{
+		UpdateOptions: options.Update().SetUpsert(true),
+	})
+}
+
+func (r *WatchlistRepository) Delete(platform, userID string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := r.collection.RemoveAll(ctx, bson.M{"platform": platform, "user_id": userID})
+	return err
+}
+
+func (r *WatchlistRepository) RecordNotification(id primitive.ObjectID, windowStart time.Time, windowCount int) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return r.collection.UpdateId(ctx, id, bson.M{
+		operator.Set: bson.M{
+			"window_start": windowStart,
+			"window_count": windowCount,
+			"updateAt":     time.Now(),
+		},
+	})
+}
+
+func (r *WatchlistRepository) AddPending(id primitive.ObjectID, newsIDs []primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	return r.collection.UpdateId(ctx, id, bson.M{
+		operator.AddToSet: bson.M{
+			"pending": bson.M{operator.Each: newsIDs},
+		},
+		operator.Set: bson.M{
+			"updateAt": time.Now(),
+		},
+	})
+}
+
+func (r *WatchlistRepository) ClearPending(id primitive.ObjectID, newsIDs []primitive.ObjectID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	now := time.Now()
+
+	return r.collection.UpdateId(ctx, id, bson.M{
+		operator.PullAll: bson.M{
+			"pending": newsIDs,
+		},
+		operator.Set: bson.M{
+			"last_digest_at": now,
+			"updateAt":       now,
+		},
+	})
+}
@@ -0,0 +1,79 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"go-nelson/pkg/structures"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+const (
+	LanguageEnglish = "en"
+	LanguageRussian = "ru"
+)
+
+// Configure заменяет логгер по умолчанию. Сообщения в коде пишутся на английском,
+// чтобы их было удобно искать, а при language=ru переводятся по словарю при выводе
+func Configure(config structures.LoggingConfigStruct) error {
+	handler, err := newHandler(config, os.Stderr)
+	if err != nil {
+		return err
+	}
+
+	slog.SetDefault(slog.New(handler))
+	return nil
+}
+
+func newHandler(config structures.LoggingConfigStruct, output io.Writer) (slog.Handler, error) {
+	var level slog.Level
+	if config.Level != "" {
+		if err := level.UnmarshalText([]byte(config.Level)); err != nil {
+			return nil, fmt.Errorf("некорректный уровень логирования %q", config.Level)
+		}
+	}
+
+	options := &slog.HandlerOptions{Level: level}
+
+	var handler slog.Handler
+	switch strings.ToLower(config.Format) {
+	case "", "text":
+		handler = slog.NewTextHandler(output, options)
+	case "json":
+		handler = slog.NewJSONHandler(output, options)
+	default:
+		return nil, fmt.Errorf("неизвестный формат логов %q", config.Format)
+	}
+
+	switch strings.ToLower(config.Language) {
+	case "", LanguageRussian:
+		return &translatingHandler{Handler: handler, messages: messagesRu}, nil
+	case LanguageEnglish:
+		return handler, nil
+	default:
+		return nil, fmt.Errorf("неизвестный язык логов %q", config.Language)
+	}
+}
+
+// Переводится только текст сообщения, ключи и значения полей остаются неизменными
+type translatingHandler struct {
+	slog.Handler
+	messages map[string]string
+}
+
+func (h *translatingHandler) Handle(ctx context.Context, record slog.Record) error {
+	if translated, ok := h.messages[record.Message]; ok {
+		record.Message = translated
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h *translatingHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &translatingHandler{Handler: h.Handler.WithAttrs(attrs), messages: h.messages}
+}
+
+func (h *translatingHandler) WithGroup(name string) slog.Handler {
+	return &translatingHandler{Handler: h.Handler.WithGroup(name), messages: h.messages}
+}
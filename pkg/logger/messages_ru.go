@@ -0,0 +1,174 @@
+package logger
+
+// Ключи совпадают с текстом сообщений в коде, сообщения без перевода выводятся как есть
+var messagesRu = map[string]string{
+	"Admin action failed":        "Ошибка действия в панели управления",
+	"All services started":       "Все сервисы успешно запущены",
+	"All services stopped":       "Все сервисы успешно остановлены",
+	"Closing Discord connection": "Закрытие соединения с Discord",
+	"Configured source has no enabled parsers entry and will not run": "Для источника нет включенной записи в parsers, он не будет запускаться",
+	"Creating Discord forum tag":                                      "Создание нового тега для форума Discord",
+	"Daily digest disabled":                                           "Ежедневная сводка отключена",
+	"Database not initialized":                                        "База данных не инициализирована",
+	"Delivery failed permanently":                                     "Доставка окончательно не удалась",
+	"Delivery interrupted by shutdown":                                "Доставка прервана остановкой",
+	"Delivery worker stopped":                                         "Обработчик очереди доставки остановлен",
+	"Discord channel belongs to another guild":                        "Канал принадлежит другому серверу",
+	"Discord channel is not a forum, news will be sent as messages":   "Канал не является форумом, новости будут отправляться сообщениями",
+	"Discord command failed":                                          "Ошибка при выполнении команды Discord",
+	"Discord commands registered":                                     "Команды Discord зарегистрированы",
+	"Discord service started":                                         "Discord сервис успешно запущен",
+	"Discord webhook rate limited, retrying":                          "Вебхук Discord ограничен по частоте, повтор",
+	"Enqueueing news edits for delivery":                              "Постановка правок новостей в очередь доставки",
+	"Enqueueing news for delivery":                                    "Постановка новостей в очередь доставки",
+	"Enqueueing news saved without deliveries":                        "Постановка в очередь новостей, сохраненных без доставок",
+	"Epic Games API returned errors":                                  "API Epic Games вернул ошибки",
+	"Failed to add news to digest":                                    "Ошибка при добавлении новостей в сводку",
+	"Failed to answer inline query":                                   "Ошибка при ответе на inline-запрос",
+	"Failed to cancel delivery of hidden news":                        "Ошибка при отмене доставки скрытой новости",
+	"Failed to cancel delivery of retracted news":                     "Ошибка при отмене доставки отозванной новости",
+	"Failed to check existing news":                                   "Ошибка при проверке существующих новостей",
+	"Failed to check retracted news":                                  "Ошибка при проверке отозванных новостей",
+	"Failed to clear delivery pending flag":                           "Ошибка при снятии отметки ожидания доставки",
+	"Failed to clear digest":                                          "Ошибка при очистке сводки",
+	"Failed to close MongoDB connection":                              "Ошибка при закрытии подключения к MongoDB",
+	"Failed to connect to Discord":                                    "Ошибка при подключении к Discord",
+	"Failed to connect to Telegram API, check the token, access to api.telegram.org and network settings": "Ошибка подключения к Telegram API, проверьте правильность токена, доступ к api.telegram.org и настройки сети",
+	"Failed to convert webp to jpg":      "Ошибка при конвертации webp в jpg",
+	"Failed to create Discord forum tag": "Ошибка при создании тега",
+	"Failed to create Discord session":   "Ошибка при создании Discord сессии",
+	"Failed to create Discord thread":    "Ошибка создания треда для новости",
+	"Failed to create Telegram bot, check the token, access to api.telegram.org and network settings": "Ошибка создания Telegram бота, проверьте правильность токена, доступ к api.telegram.org и настройки сети",
+	"Failed to create index":                                                 "Ошибка при создании индекса",
+	"Failed to create text index":                                            "Ошибка при создании текстового индекса",
+	"Failed to delete description continuation":                              "Ошибка при удалении продолжения описания",
+	"Failed to deliver news":                                                 "Ошибка при отправке новости",
+	"Failed to edit Telegram album photo":                                    "Ошибка при замене фото альбома Telegram",
+	"Failed to edit description continuation":                                "Ошибка при редактировании продолжения описания",
+	"Failed to enqueue delivery":                                             "Ошибка при постановке новости в очередь доставки",
+	"Failed to enqueue news edit":                                            "Ошибка при постановке правки новости в очередь",
+	"Failed to enqueue news retraction":                                      "Ошибка при постановке отзыва новости в очередь",
+	"Failed to get Discord channel info":                                     "Ошибка при получении информации о канале",
+	"Failed to get delivery queue stats for metrics":                         "Ошибка при получении состояния очереди доставки для метрик",
+	"Failed to get forum info to create tag":                                 "Ошибка при получении информации о форуме для создания тега",
+	"Failed to get source statistics":                                        "Ошибка при получении статистики источников",
+	"Failed to get source statistics for API":                                "Ошибка при получении статистики источников для API",
+	"Failed to get source statistics for admin dashboard":                    "Ошибка при получении статистики источников для панели управления",
+	"Failed to initialize database":                                          "Ошибка подключения к базе данных",
+	"Failed to load Discord messages":                                        "Не удалось загрузить сообщения Discord",
+	"Failed to load config":                                                  "Ошибка загрузки конфигурации",
+	"Failed to load delivery queue for admin dashboard":                      "Ошибка при загрузке очереди доставки для панели управления",
+	"Failed to load delivery queue news":                                     "Ошибка при загрузке новостей очереди доставки",
+	"Failed to load digest news":                                             "Ошибка при загрузке новостей сводки",
+	"Failed to load digests":                                                 "Ошибка при загрузке сводок",
+	"Failed to load news for API":                                            "Ошибка при загрузке новостей для API",
+	"Failed to load news for admin dashboard":                                "Ошибка при загрузке новостей для панели управления",
+	"Failed to load news for feed":                                           "Ошибка при загрузке новостей для ленты",
+	"Failed to load news item for API":                                       "Ошибка при загрузке новости для API",
+	"Failed to load news page":                                               "Ошибка при загрузке страницы новостей",
+	"Failed to load news pending delivery":                                   "Ошибка при загрузке новостей, ожидающих постановки в очередь",
+	"Failed to load stored news":                                             "Ошибка при поиске сохраненных новостей",
+	"Failed to load watchlists":                                              "Ошибка при загрузке списков отслеживания",
+	"Failed to mark Telegram album photo":                                    "Ошибка при пометке фото альбома Telegram",
+	"Failed to mark delivery as failed":                                      "Ошибка при отметке неудачной доставки",
+	"Failed to mark delivery as sent":                                        "Ошибка при отметке доставки новости",
+	"Failed to mark news as retracted":                                       "Ошибка при отметке отозванной новости",
+	"Failed to parse promotion end date":                                     "Ошибка при парсинге даты окончания акции",
+	"Failed to parse promotion start date":                                   "Ошибка при парсинге даты начала акции",
+	"Failed to parse publication date":                                       "Ошибка при парсинге даты публикации",
+	"Failed to parse source":                                                 "Ошибка при парсинге источника",
+	"Failed to parse upcoming promotion end date":                            "Ошибка при парсинге даты окончания предстоящей акции",
+	"Failed to parse upcoming promotion start date":                          "Ошибка при парсинге даты начала предстоящей акции",
+	"Failed to prepare image":                                                "Ошибка при подготовке изображения",
+	"Failed to read HTTP cache":                                              "Ошибка при чтении HTTP-кеша",
+	"Failed to read delivery queue":                                          "Ошибка при чтении очереди доставки",
+	"Failed to register Discord commands":                                    "Ошибка при регистрации команд Discord",
+	"Failed to render admin dashboard":                                       "Ошибка при отрисовке панели управления",
+	"Failed to render feed":                                                  "Ошибка при формировании ленты",
+	"Failed to reply in Telegram":                                            "Ошибка при ответе в Telegram",
+	"Failed to reschedule delivery":                                          "Ошибка при переносе доставки",
+	"Failed to reset missing runs":                                           "Ошибка при сбросе счетчика пропусков новостей",
+	"Failed to respond to Discord autocomplete":                              "Ошибка при ответе на автодополнение Discord",
+	"Failed to respond to Discord button":                                    "Ошибка при ответе на кнопку Discord",
+	"Failed to respond to Discord command":                                   "Ошибка при ответе на команду Discord",
+	"Failed to save HTTP cache":                                              "Ошибка при сохранении HTTP-кеша",
+	"Failed to save message ID":                                              "Ошибка при сохранении ID сообщения",
+	"Failed to save news":                                                    "Ошибка при сохранении новости",
+	"Failed to save notification rate limit":                                 "Ошибка при сохранении лимита уведомлений",
+	"Failed to search news for API":                                          "Ошибка при поиске новостей для API",
+	"Failed to search news for Telegram":                                     "Ошибка при поиске новостей для Telegram",
+	"Failed to search news for inline query":                                 "Ошибка при поиске новостей для inline-запроса",
+	"Failed to send Discord command result":                                  "Ошибка при отправке результата команды Discord",
+	"Failed to send Telegram album, falling back to single photo":            "Ошибка отправки альбома в Telegram, отправка одного изображения",
+	"Failed to send Telegram photo, falling back to text":                    "Ошибка отправки фото в Telegram, отправка текстом",
+	"Failed to send description continuation":                                "Ошибка отправки дополнительной части описания",
+	"Failed to send digest":                                                  "Ошибка при отправке сводки",
+	"Failed to send watchlist notification":                                  "Ошибка при отправке уведомления пользователю",
+	"Failed to set Telegram commands":                                        "Ошибка при установке списка команд Telegram",
+	"Failed to stop HTTP server":                                             "Ошибка при остановке HTTP-сервера",
+	"Failed to update missing runs":                                          "Ошибка при обновлении счетчика пропусков новости",
+	"Failed to update news":                                                  "Ошибка при обновлении новости",
+	"Failed to update news page":                                             "Ошибка при обновлении страницы новостей",
+	"Failed to write API response":                                           "Ошибка при отправке ответа API",
+	"HTTP profile not found, using default":                                  "HTTP-профиль не найден, используется профиль по умолчанию",
+	"HTTP server failed":                                                     "Ошибка HTTP-сервера",
+	"HTTP validators not saved because some news were not stored":            "Валидаторы HTTP не сохранены, так как часть новостей не удалось сохранить",
+	"Image skipped in Telegram album":                                        "Изображение пропущено в альбоме Telegram",
+	"Initializing Discord forum tags":                                        "Инициализация тегов форума Discord",
+	"Invalid HTTP config":                                                    "Некорректная конфигурация HTTP",
+	"Invalid delivery poll interval, using default":                          "Некорректный интервал опроса очереди доставки, используется значение по умолчанию",
+	"Invalid feed config":                                                    "Ошибка в конфигурации ленты",
+	"Invalid feed max_age, using default":                                    "Некорректный max_age ленты, используется значение по умолчанию",
+	"Invalid logging config":                                                 "Некорректная конфигурация логирования",
+	"Invalid routing config":                                                 "Некорректная конфигурация маршрутизации",
+	"Invalid scheduler config":                                               "Ошибка в настройках планировщика",
+	"Invalid scraper config":                                                 "Ошибка в конфигурации скрапера",
+	"Invalid shutdown timeout, using default":                                "Некорректный таймаут остановки, используется значение по умолчанию",
+	"Invalid source retraction config":                                       "Ошибка в настройках отзыва источника",
+	"Invalid source schedule":                                                "Ошибка в расписании источника",
+	"News changed, edit enqueued":                                            "Новость изменилась, правка поставлена в очередь",
+	"News deleted":                                                           "Новость удалена",
+	"News marked as retracted":                                               "Новость помечена как отозванная",
+	"News missing from source":                                               "Новость отсутствует в выдаче источника",
+	"News not published, skipping edit":                                      "Новость не опубликована, редактирование пропущено",
+	"News not saved to database, cannot deliver":                             "Новость не сохранена в базе, доставка невозможна",
+	"News parser failed":                                                     "Ошибка парсера новостей",
+	"News parser stopped":                                                    "Парсер новостей остановлен",
+	"News removed by source":                                                 "Новость удалена источником",
+	"News retraction enqueued":                                               "Отзыв новости поставлен в очередь",
+	"News sent to webhook":                                                   "Новость отправлена в вебхук",
+	"News updated":                                                           "Новость обновлена",
+	"No active news sources scheduled":                                       "Нет активных источников новостей",
+	"No destinations matched news":                                           "Для новости не найдено ни одного назначения",
+	"Parsing source":                                                         "Парсинг источника",
+	"Previous source run still in progress, run skipped":                     "Предыдущий запуск источника еще не завершен, запуск пропущен",
+	"Processing changed news":                                                "Обработка измененных новостей",
+	"Processing new news":                                                    "Обработка новых новостей",
+	"Retry-After exceeds max retry delay, giving up":                         "Retry-After превышает максимальную задержку повтора, запрос не повторяется",
+	"Retrying request":                                                       "Повтор запроса",
+	"Route configured":                                                       "Маршрут настроен",
+	"Routing default is empty, news matching no route will not be delivered": "Назначения по умолчанию не заданы, новости без подходящего маршрута не будут доставлены",
+	"Services did not stop cleanly":                                          "Сервисы остановлены с ошибками",
+	"Shutdown complete":                                                      "Приложение остановлено",
+	"Shutting down":                                                          "Остановка приложения",
+	"Source already registered, registration overwritten":                    "Источник уже зарегистрирован, регистрация перезаписана",
+	"Source configured but not registered":                                   "Источник указан в конфигурации, но не зарегистрирован",
+	"Source not modified since last run":                                     "Источник не изменился с прошлого запуска",
+	"Source processed":                                                       "Источник обработан",
+	"Source run interrupted by shutdown":                                     "Запуск источника прерван остановкой",
+	"Source scheduled":                                                       "Источник запланирован",
+	"Source uses unknown HTTP profile":                                       "Источник использует неизвестный HTTP-профиль",
+	"Starting Discord service":                                               "Запуск Discord сервиса",
+	"Starting HTTP server":                                                   "Запуск HTTP-сервера",
+	"Starting delivery worker":                                               "Запуск обработчика очереди доставки",
+	"Starting news parser":                                                   "Запуск парсера новостей",
+	"Starting parser pool":                                                   "Запуск пула парсеров",
+	"Starting services":                                                      "Запуск всех сервисов",
+	"Stopping services":                                                      "Остановка всех сервисов",
+	"Telegram bot configured":                                                "Telegram бот успешно настроен",
+	"Telegram bot started":                                                   "Telegram бот успешно запущен",
+	"Telegram bot stopped":                                                   "Telegram бот остановлен",
+	"Telegram command failed":                                                "Ошибка при выполнении команды Telegram",
+	"Watchlist daily digest scheduled":                                       "Ежедневная сводка списков отслеживания запланирована",
+	"Webhook secret not set, requests will not be signed":                    "Для вебхука не указан secret, запросы не будут подписаны",
+}
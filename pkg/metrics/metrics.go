@@ -0,0 +1,120 @@
+package metrics
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const namespace = "nelson"
+
+var (
+	SourceFetchDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "source_fetch_duration_seconds",
+		Help:      "Длительность запуска источника",
+		Buckets:   []float64{0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 120},
+	}, []string{"source"})
+
+	SourceRuns = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "source_runs_total",
+		Help:      "Запуски источников по результату: ok, not_modified, error",
+	}, []string{"source", "result"})
+
+	SourceHTTPResponses = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "source_http_responses_total",
+		Help:      "HTTP-ответы при загрузке источников по коду статуса",
+	}, []string{"source", "code"})
+
+	SourceParseErrors = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "source_parse_errors_total",
+		Help:      "Запуски источников, завершившиеся ошибкой",
+	}, []string{"source"})
+
+	SourceItemsFound = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "source_items_found_total",
+		Help:      "Новости, найденные источником, включая уже известные",
+	}, []string{"source"})
+
+	SourceNewItems = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "source_new_items_total",
+		Help:      "Новые новости источника",
+	}, []string{"source"})
+
+	SourceLastSuccess = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "source_last_success_timestamp_seconds",
+		Help:      "Время последнего успешного запуска источника",
+	}, []string{"source"})
+
+	DeliverySends = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "delivery_sends_total",
+		Help:      "Попытки доставки по назначению, действию и результату: success, failure",
+	}, []string{"destination", "type", "action", "result"})
+
+	RateLimitHits = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "rate_limit_hits_total",
+		Help:      "Срабатывания ограничений частоты запросов платформ",
+	}, []string{"platform"})
+
+	ImageConversionFailures = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "image_conversion_failures_total",
+		Help:      "Ошибки конвертации изображений",
+	})
+
+	ImagePreparationFailures = prometheus.NewCounter(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "image_preparation_failures_total",
+		Help:      "Изображения, которые не удалось скачать или уложить в ограничение размера",
+	})
+
+	MongoOperationDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "mongo_operation_duration_seconds",
+		Help:      "Длительность команд MongoDB",
+		Buckets:   []float64{0.001, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 5},
+	}, []string{"command", "result"})
+
+	Registry = prometheus.NewRegistry()
+)
+
+func init() {
+	Registry.MustRegister(
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+		SourceFetchDuration,
+		SourceRuns,
+		SourceHTTPResponses,
+		SourceParseErrors,
+		SourceItemsFound,
+		SourceNewItems,
+		SourceLastSuccess,
+		DeliverySends,
+		RateLimitHits,
+		ImageConversionFailures,
+		ImagePreparationFailures,
+		MongoOperationDuration,
+	)
+}
+
+type sourceKey struct{}
+
+// WithSource помечает контекст запуска источника, чтобы загрузчик учитывал HTTP-ответы по источнику
+func WithSource(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, sourceKey{}, name)
+}
+
+func RecordHTTPResponse(ctx context.Context, statusCode int) {
+	if name, ok := ctx.Value(sourceKey{}).(string); ok && name != "" {
+		SourceHTTPResponses.WithLabelValues(name, strconv.Itoa(statusCode)).Inc()
+	}
+}
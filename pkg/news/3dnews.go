@@ -1,13 +1,14 @@
 package news
 
 import (
+	"context"
 	"crypto/md5"
 	"encoding/hex"
 	"encoding/xml"
 	"fmt"
 	"go-nelson/pkg/structures"
 	"go-nelson/pkg/utils"
-	"log"
+	"log/slog"
 	"strings"
 )
 
@@ -38,14 +39,23 @@ type ThreeDNewsItem struct {
 	} `xml:"enclosure"`
 }
 
-func Parse3DNews() ([]structures.News, error) {
-	log.Println("Парсинг новостей с 3DNews")
+func init() {
+	RegisterSource(&parserSource{
+		name:     "3dnews",
+		provider: "3DNews",
+		tag:      "3Dnews",
+		parse:    Parse3DNews,
+	})
+}
+
+func Parse3DNews(ctx context.Context) ([]structures.News, error) {
+	slog.Debug("Parsing source", "provider", "3DNews")
 	var news []structures.News
-	fetcher := utils.NewFetcher()
+	fetcher := utils.NewConditionalFetcher()
 
-	data, err := fetcher.Fetch("https://3dnews.ru/news/rss/")
+	data, err := fetcher.FetchContext(ctx, "https://3dnews.ru/news/rss/")
 	if err != nil {
-		return nil, fmt.Errorf("ошибка при запросе RSS-фида 3DNews: %v", err)
+		return nil, fmt.Errorf("ошибка при запросе RSS-фида 3DNews: %w", err)
 	}
 
 	var rss ThreeDNewsRSS
@@ -62,7 +72,7 @@ func Parse3DNews() ([]structures.News, error) {
 
 		_, err := utils.ParseRSSDate(item.PubDate)
 		if err != nil {
-			log.Printf("Ошибка при парсинге даты публикации: %v", err)
+			slog.Warn("Failed to parse publication date", "provider", "3DNews", "error", err)
 		}
 
 		hash := md5.Sum([]byte(item.Link))
@@ -1,13 +1,14 @@
 package news
 
 import (
+	"context"
 	"crypto/md5"
 	"encoding/hex"
 	"encoding/xml"
 	"fmt"
 	"go-nelson/pkg/structures"
 	"go-nelson/pkg/utils"
-	"log"
+	"log/slog"
 )
 
 type DMenRSS struct {
@@ -34,14 +35,23 @@ type DMenItem struct {
 	} `xml:"enclosure"`
 }
 
-func ParseDMen() ([]structures.News, error) {
-	log.Println("Парсинг новостей с DisgustingMen")
+func init() {
+	RegisterSource(&parserSource{
+		name:     "disgustingmen",
+		provider: "DisgustingMen",
+		tag:      "Disgusting",
+		parse:    ParseDMen,
+	})
+}
+
+func ParseDMen(ctx context.Context) ([]structures.News, error) {
+	slog.Debug("Parsing source", "provider", "DisgustingMen")
 	var news []structures.News
-	fetcher := utils.NewFetcher()
+	fetcher := utils.NewConditionalFetcher()
 
-	data, err := fetcher.Fetch("https://disgustingmen.com/feed/")
+	data, err := fetcher.FetchContext(ctx, "https://disgustingmen.com/feed/")
 	if err != nil {
-		return nil, fmt.Errorf("ошибка при запросе RSS-фида DisgustingMen: %v", err)
+		return nil, fmt.Errorf("ошибка при запросе RSS-фида DisgustingMen: %w", err)
 	}
 
 	var rss DMenRSS
@@ -59,7 +69,7 @@ func ParseDMen() ([]structures.News, error) {
 
 		_, err := utils.ParseRSSDate(item.PubDate)
 		if err != nil {
-			log.Printf("Ошибка при парсинге даты публикации: %v", err)
+			slog.Warn("Failed to parse publication date", "provider", "DisgustingMen", "error", err)
 		}
 
 		hash := md5.Sum([]byte(item.Link))
@@ -1,13 +1,14 @@
 package news
 
 import (
+	"context"
 	"crypto/md5"
 	"encoding/hex"
 	"encoding/xml"
 	"fmt"
 	"go-nelson/pkg/structures"
 	"go-nelson/pkg/utils"
-	"log"
+	"log/slog"
 	"strings"
 )
 
@@ -47,14 +48,23 @@ type DTFItem struct {
 	} `xml:"enclosure"`
 }
 
-func ParseDTF() ([]structures.News, error) {
-	log.Println("Парсинг новостей с DTF")
+func init() {
+	RegisterSource(&parserSource{
+		name:     "dtf",
+		provider: "DTF",
+		tag:      "DTF",
+		parse:    ParseDTF,
+	})
+}
+
+func ParseDTF(ctx context.Context) ([]structures.News, error) {
+	slog.Debug("Parsing source", "provider", "DTF")
 	var news []structures.News
-	fetcher := utils.NewFetcher()
+	fetcher := utils.NewConditionalFetcher()
 
-	data, err := fetcher.Fetch("https://dtf.ru/rss")
+	data, err := fetcher.FetchContext(ctx, "https://dtf.ru/rss")
 	if err != nil {
-		return nil, fmt.Errorf("ошибка при запросе RSS-фида DTF: %v", err)
+		return nil, fmt.Errorf("ошибка при запросе RSS-фида DTF: %w", err)
 	}
 
 	var rss DTFRSS
@@ -71,7 +81,7 @@ func ParseDTF() ([]structures.News, error) {
 
 		_, err := utils.ParseRSSDate(item.PubDate)
 		if err != nil {
-			log.Printf("Ошибка при парсинге даты публикации: %v", err)
+			slog.Warn("Failed to parse publication date", "provider", "DTF", "error", err)
 		}
 
 		idStr := item.Link
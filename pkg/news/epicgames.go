@@ -1,13 +1,14 @@
 package news
 
 import (
+	"context"
 	"crypto/md5"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"go-nelson/pkg/structures"
 	"go-nelson/pkg/utils"
-	"log"
+	"log/slog"
 	"time"
 )
 
@@ -77,14 +78,23 @@ type EpicGamesElement struct {
 	UrlSlug     string `json:"urlSlug"`
 }
 
-func ParseEpicGamesStore() ([]structures.News, error) {
-	log.Println("Парсинг новостей с Epic Games Store")
+func init() {
+	RegisterSource(&parserSource{
+		name:     "epicgames",
+		provider: "Epic Games Store",
+		tag:      "EGS",
+		parse:    ParseEpicGamesStore,
+	})
+}
+
+func ParseEpicGamesStore(ctx context.Context) ([]structures.News, error) {
+	slog.Debug("Parsing source", "provider", "Epic Games Store")
 	var news []structures.News
-	fetcher := utils.NewFetcher()
+	fetcher := utils.NewConditionalFetcher()
 
-	data, err := fetcher.Fetch("https://store-site-backend-static.ak.epicgames.com/freeGamesPromotions")
+	data, err := fetcher.FetchContext(ctx, "https://store-site-backend-static.ak.epicgames.com/freeGamesPromotions")
 	if err != nil {
-		return nil, fmt.Errorf("ошибка при запросе API Epic Games Store: %v", err)
+		return nil, fmt.Errorf("ошибка при запросе API Epic Games Store: %w", err)
 	}
 
 	var response EpicGamesResponse
@@ -93,7 +103,7 @@ func ParseEpicGamesStore() ([]structures.News, error) {
 	}
 
 	if len(response.Errors) > 0 {
-		log.Printf("API Epic Games вернул ошибки: %v", response.Errors)
+		slog.Warn("Epic Games API returned errors", "provider", "Epic Games Store", "errors", response.Errors)
 	}
 
 	currentTime := time.Now()
@@ -116,6 +126,13 @@ func ParseEpicGamesStore() ([]structures.News, error) {
 		if currentlyFree {
 			newsItem := createNewsDbItem(game, imageURL, gameURL, "Сейчас бесплатно", startDate, endDate)
 
+			// Срок акции неизвестен: даты нужны только для выборки активных предложений и в описание не попадают,
+			// иначе текст менялся бы при каждом запуске
+			if startDate.IsZero() {
+				newsItem.PublishedAt = currentTime
+				newsItem.ExpiresAt = currentTime.AddDate(0, 0, 7)
+			}
+
 			if game.Price.TotalPrice.OriginalPrice > 0 && game.Price.TotalPrice.DiscountPrice == 0 {
 				originalPrice := game.Price.TotalPrice.FmtPrice.OriginalPrice
 				newsItem.Description += fmt.Sprintf("\n\nОбычная цена: %s", originalPrice)
@@ -160,7 +177,7 @@ func isGameFree(game EpicGamesElement, currentTime time.Time) (bool, time.Time,
 	if game.Promotions == nil || len(game.Promotions.PromotionalOffers) == 0 {
 		if game.Price.TotalPrice.OriginalPrice == 0 ||
 			(game.Price.TotalPrice.OriginalPrice > 0 && game.Price.TotalPrice.DiscountPrice == 0) {
-			return true, time.Now(), time.Now().AddDate(0, 0, 7)
+			return true, time.Time{}, time.Time{}
 		}
 		return false, time.Time{}, time.Time{}
 	}
@@ -169,13 +186,13 @@ func isGameFree(game EpicGamesElement, currentTime time.Time) (bool, time.Time,
 		for _, offer := range promo.PromotionalOffers {
 			startDate, err := time.Parse(time.RFC3339, offer.StartDate)
 			if err != nil {
-				log.Printf("Ошибка при парсинге даты начала акции: %v", err)
+				slog.Warn("Failed to parse promotion start date", "provider", "Epic Games Store", "error", err)
 				continue
 			}
 
 			endDate, err := time.Parse(time.RFC3339, offer.EndDate)
 			if err != nil {
-				log.Printf("Ошибка при парсинге даты окончания акции: %v", err)
+				slog.Warn("Failed to parse promotion end date", "provider", "Epic Games Store", "error", err)
 				continue
 			}
 
@@ -191,7 +208,7 @@ func isGameFree(game EpicGamesElement, currentTime time.Time) (bool, time.Time,
 	}
 
 	if game.Price.TotalPrice.OriginalPrice > 0 && game.Price.TotalPrice.DiscountPrice == 0 {
-		return true, time.Now(), time.Now().AddDate(0, 0, 7)
+		return true, time.Time{}, time.Time{}
 	}
 
 	return false, time.Time{}, time.Time{}
@@ -216,13 +233,13 @@ func isGameUpcomingFree(game EpicGamesElement, currentTime time.Time) (bool, tim
 		for _, offer := range promo.PromotionalOffers {
 			startDate, err := time.Parse(time.RFC3339, offer.StartDate)
 			if err != nil {
-				log.Printf("Ошибка при парсинге даты начала предстоящей акции: %v", err)
+				slog.Warn("Failed to parse upcoming promotion start date", "provider", "Epic Games Store", "error", err)
 				continue
 			}
 
 			endDate, err := time.Parse(time.RFC3339, offer.EndDate)
 			if err != nil {
-				log.Printf("Ошибка при парсинге даты окончания предстоящей акции: %v", err)
+				slog.Warn("Failed to parse upcoming promotion end date", "provider", "Epic Games Store", "error", err)
 				continue
 			}
 
@@ -301,14 +318,18 @@ func createNewsDbItem(game EpicGamesElement, imageURL, gameURL, status string, s
 		availableSoon = "скоро будет бесплатным"
 	}
 
+	availability := availableSoon
 	if status == "Сейчас бесплатно" {
-		title = fmt.Sprintf("%s %s %s в Epic Games Store", gameType, game.Title, availableNow)
+		availability = availableNow
+	}
+
+	title = fmt.Sprintf("%s %s %s в Epic Games Store", gameType, game.Title, availability)
+	if dateRange != "" {
 		content = fmt.Sprintf("%s\n\n%s %s в период: %s",
-			game.Description, gameType, availableNow, dateRange)
+			game.Description, gameType, availability, dateRange)
 	} else {
-		title = fmt.Sprintf("%s %s %s в Epic Games Store", gameType, game.Title, availableSoon)
-		content = fmt.Sprintf("%s\n\n%s %s в период: %s",
-			game.Description, gameType, availableSoon, dateRange)
+		content = fmt.Sprintf("%s\n\n%s %s",
+			game.Description, gameType, availability)
 	}
 
 	var images []string
@@ -323,6 +344,8 @@ func createNewsDbItem(game EpicGamesElement, imageURL, gameURL, status string, s
 		Description: content,
 		URL:         gameURL,
 		Images:      images,
+		PublishedAt: startDate,
+		ExpiresAt:   endDate,
 	}
 }
 
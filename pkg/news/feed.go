@@ -0,0 +1,286 @@
+package news
+
+import (
+	"context"
+	"crypto/md5"
+	"encoding/hex"
+	"fmt"
+	"go-nelson/pkg"
+	"go-nelson/pkg/structures"
+	"go-nelson/pkg/utils"
+	"log/slog"
+	"path"
+	"strings"
+)
+
+var defaultFeedImageRules = []string{"enclosure", "media", "image", "description"}
+
+type feedSource struct {
+	config structures.FeedConfigStruct
+}
+
+func NewFeedSource(config structures.FeedConfigStruct) (Source, error) {
+	if config.Name == "" {
+		return nil, fmt.Errorf("не указано имя ленты")
+	}
+	if config.Provider == "" {
+		return nil, fmt.Errorf("не указан провайдер ленты %s", config.Name)
+	}
+	if config.URL == "" {
+		return nil, fmt.Errorf("не указан URL ленты %s", config.Name)
+	}
+
+	switch config.Format {
+	case "", "rss", "atom", "rdf", "json":
+	default:
+		return nil, fmt.Errorf("неизвестный формат ленты %s: %s", config.Name, config.Format)
+	}
+
+	if !isValidUniqueIDStrategy(config.UniqueID) {
+		return nil, fmt.Errorf("неизвестная стратегия уникального ID ленты %s: %s", config.Name, config.UniqueID)
+	}
+
+	return &feedSource{config: config}, nil
+}
+
+func (s *feedSource) Name() string {
+	return s.config.Name
+}
+
+func (s *feedSource) Provider() string {
+	return s.config.Provider
+}
+
+func (s *feedSource) Tag() string {
+	return s.config.Tag
+}
+
+func (s *feedSource) Parse(ctx context.Context) ([]structures.News, error) {
+	slog.Debug("Parsing source", "provider", s.config.Provider)
+	var news []structures.News
+	fetcher := utils.NewConditionalFetcher()
+
+	data, err := fetcher.FetchContext(ctx, s.config.URL)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при запросе ленты %s: %w", s.config.Provider, err)
+	}
+
+	entries, err := parseFeedEntries(data, s.config.Format)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при парсинге ленты %s: %w", s.config.Provider, err)
+	}
+
+	for _, entry := range entries {
+		title := strings.TrimSpace(utils.CleanHTML(entry.Title))
+		link := strings.TrimSpace(entry.Link)
+		if title == "" || link == "" {
+			continue
+		}
+
+		description := s.description(entry)
+		if description == "" && s.config.Description.SkipEmpty {
+			continue
+		}
+
+		newsItem := structures.News{
+			Provider:    s.config.Provider,
+			UniqueID:    s.uniqueID(entry),
+			Title:       title,
+			Description: description,
+			URL:         link,
+			Images:      s.images(entry),
+			Tags:        s.tags(entry),
+		}
+
+		if entry.PubDate != "" {
+			publishedAt, err := utils.ParseRSSDate(strings.TrimSpace(entry.PubDate))
+			if err != nil {
+				slog.Warn("Failed to parse publication date", "provider", s.config.Provider, "error", err)
+			} else {
+				newsItem.PublishedAt = publishedAt
+			}
+		}
+		news = append(news, newsItem)
+	}
+
+	return news, nil
+}
+
+func (s *feedSource) uniqueID(entry feedEntry) string {
+	return makeUniqueID(s.config.UniqueID, s.config.UniqueIDPrefix, s.config.UniqueIDPart, entry.GUID, entry.Link)
+}
+
+func (s *feedSource) description(entry feedEntry) string {
+	description := entry.Description
+	fallback := entry.Content
+	if s.config.Description.Source == "content" {
+		description, fallback = fallback, description
+	}
+	if strings.TrimSpace(description) == "" {
+		description = fallback
+	}
+
+	return applyDescriptionRule(s.config.Description, description)
+}
+
+func (s *feedSource) images(entry feedEntry) []string {
+	rules := s.config.Image
+	if len(rules) == 0 {
+		rules = defaultFeedImageRules
+	}
+
+	var imageURL string
+	for _, rule := range rules {
+		switch rule {
+		case "enclosure":
+			for _, enclosure := range entry.Enclosures {
+				if enclosure.URL != "" && (enclosure.Type == "" || strings.Contains(enclosure.Type, "image")) {
+					imageURL = enclosure.URL
+					break
+				}
+			}
+		case "media":
+			if len(entry.MediaURLs) > 0 {
+				imageURL = entry.MediaURLs[0]
+			}
+		case "image":
+			imageURL = entry.Image
+		case "description":
+			imageURL = utils.ExtractImageURL(entry.Description)
+			if imageURL == "" {
+				imageURL = utils.ExtractImageURL(entry.Content)
+			}
+		case "none":
+			return nil
+		}
+
+		if imageURL != "" {
+			break
+		}
+	}
+
+	if imageURL == "" {
+		imageURL = s.config.DefaultImage
+	}
+
+	if imageURL == "" {
+		return nil
+	}
+	return []string{imageURL}
+}
+
+func (s *feedSource) tags(entry feedEntry) []string {
+	tags := append([]string{}, s.config.Tags...)
+
+	if s.config.CategoryTags {
+		for _, category := range entry.Categories {
+			category = strings.TrimSpace(category)
+			if category != "" {
+				tags = append(tags, category)
+			}
+		}
+	}
+
+	if len(tags) == 0 {
+		return nil
+	}
+	return tags
+}
+
+func makeUniqueID(strategy, prefix string, part int, guid, link string) string {
+	guid = strings.TrimSpace(guid)
+	link = strings.TrimSpace(link)
+
+	var id string
+	switch strategy {
+	case "guid":
+		id = guid
+		if id == "" {
+			id = link
+		}
+	case "link":
+		id = link
+	case "hash_link":
+		id = hashString(link)
+	case "last_part":
+		id = strings.TrimSuffix(path.Base(strings.TrimRight(link, "/")), path.Ext(link))
+	case "part":
+		parts := strings.Split(link, "/")
+		if part >= 0 && part < len(parts) && parts[part] != "" {
+			id = parts[part]
+		} else {
+			id = link
+		}
+	default:
+		if guid != "" {
+			id = hashString(guid)
+		} else {
+			id = hashString(link)
+		}
+	}
+
+	return prefix + id
+}
+
+func isValidUniqueIDStrategy(strategy string) bool {
+	switch strategy {
+	case "", "hash", "hash_link", "guid", "link", "last_part", "part":
+		return true
+	default:
+		return false
+	}
+}
+
+func applyDescriptionRule(rule structures.FeedDescriptionRuleStruct, description string) string {
+	for _, replace := range rule.Replace {
+		description = strings.ReplaceAll(description, replace.From, replace.To)
+	}
+
+	if rule.CutAt != "" {
+		occurrence := max(rule.CutAtOccurrence, 1)
+		if idx := utils.FindNthOccurrence(description, rule.CutAt, occurrence); idx != -1 {
+			description = description[:idx]
+		}
+	}
+
+	if !rule.KeepHTML {
+		description = utils.CleanHTML(description)
+	}
+
+	return strings.TrimSpace(description)
+}
+
+func hashString(value string) string {
+	hash := md5.Sum([]byte(value))
+	return hex.EncodeToString(hash[:])
+}
+
+func RegisterConfiguredSources() {
+	for _, config := range pkg.Feeds {
+		source, err := NewFeedSource(config)
+		if err != nil {
+			slog.Error("Invalid feed config", "error", err)
+			continue
+		}
+		RegisterSource(source)
+		warnIfNotEnabled(source)
+	}
+
+	for _, config := range pkg.Scrapers {
+		source, err := NewScraperSource(config)
+		if err != nil {
+			slog.Error("Invalid scraper config", "error", err)
+			continue
+		}
+		RegisterSource(source)
+		warnIfNotEnabled(source)
+	}
+}
+
+// Ленты и скраперы запускаются только при включенной записи в parsers с тем же именем,
+// без нее источник зарегистрирован, но молча простаивает
+func warnIfNotEnabled(source Source) {
+	if !pkg.Parsers[source.Name()].Enabled {
+		slog.Warn("Configured source has no enabled parsers entry and will not run", "source", source.Name())
+	}
+}
@@ -0,0 +1,342 @@
+package news
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+
+	"golang.org/x/net/html/charset"
+)
+
+type feedEntry struct {
+	Title       string
+	Link        string
+	GUID        string
+	Description string
+	Content     string
+	PubDate     string
+	Categories  []string
+	Enclosures  []feedEnclosure
+	MediaURLs   []string
+	Image       string
+}
+
+type feedEnclosure struct {
+	URL  string
+	Type string
+}
+
+type feedMedia struct {
+	URL    string `xml:"url,attr"`
+	Medium string `xml:"medium,attr"`
+	Type   string `xml:"type,attr"`
+}
+
+type rssFeed struct {
+	XMLName xml.Name `xml:"rss"`
+	Channel struct {
+		Items []rssFeedItem `xml:"item"`
+	} `xml:"channel"`
+}
+
+type rssFeedItem struct {
+	Title          string      `xml:"title"`
+	Link           string      `xml:"link"`
+	GUID           string      `xml:"guid"`
+	Description    string      `xml:"description"`
+	ContentEncoded string      `xml:"http://purl.org/rss/1.0/modules/content/ encoded"`
+	PubDate        string      `xml:"pubDate"`
+	Date           string      `xml:"http://purl.org/dc/elements/1.1/ date"`
+	Categories     []string    `xml:"category"`
+	MediaContent   []feedMedia `xml:"http://search.yahoo.com/mrss/ content"`
+	MediaThumbnail []feedMedia `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+	Enclosures     []struct {
+		URL  string `xml:"url,attr"`
+		Type string `xml:"type,attr"`
+	} `xml:"enclosure"`
+}
+
+type atomFeed struct {
+	XMLName xml.Name        `xml:"feed"`
+	Entries []atomFeedEntry `xml:"entry"`
+}
+
+type atomFeedEntry struct {
+	Title string `xml:"title"`
+	ID    string `xml:"id"`
+	Links []struct {
+		Href string `xml:"href,attr"`
+		Rel  string `xml:"rel,attr"`
+		Type string `xml:"type,attr"`
+	} `xml:"link"`
+	Summary    atomText `xml:"summary"`
+	Content    atomText `xml:"content"`
+	Published  string   `xml:"published"`
+	Updated    string   `xml:"updated"`
+	Categories []struct {
+		Term  string `xml:"term,attr"`
+		Label string `xml:"label,attr"`
+	} `xml:"category"`
+	MediaContent   []feedMedia `xml:"http://search.yahoo.com/mrss/ content"`
+	MediaThumbnail []feedMedia `xml:"http://search.yahoo.com/mrss/ thumbnail"`
+}
+
+type atomText struct {
+	Type  string `xml:"type,attr"`
+	Text  string `xml:",chardata"`
+	Inner string `xml:",innerxml"`
+}
+
+func (t atomText) String() string {
+	if t.Type == "xhtml" {
+		return t.Inner
+	}
+	return t.Text
+}
+
+type rdfFeed struct {
+	XMLName xml.Name      `xml:"RDF"`
+	Items   []rssFeedItem `xml:"item"`
+}
+
+type jsonFeed struct {
+	Version string `json:"version"`
+	Items   []struct {
+		ID            string   `json:"id"`
+		URL           string   `json:"url"`
+		ExternalURL   string   `json:"external_url"`
+		Title         string   `json:"title"`
+		ContentHTML   string   `json:"content_html"`
+		ContentText   string   `json:"content_text"`
+		Summary       string   `json:"summary"`
+		Image         string   `json:"image"`
+		BannerImage   string   `json:"banner_image"`
+		DatePublished string   `json:"date_published"`
+		Tags          []string `json:"tags"`
+		Attachments   []struct {
+			URL      string `json:"url"`
+			MimeType string `json:"mime_type"`
+		} `json:"attachments"`
+	} `json:"items"`
+}
+
+func parseFeedEntries(data []byte, format string) ([]feedEntry, error) {
+	if format == "" {
+		format = detectFeedFormat(data)
+	}
+
+	switch format {
+	case "rss":
+		return parseRSSEntries(data)
+	case "atom":
+		return parseAtomEntries(data)
+	case "rdf":
+		return parseRDFEntries(data)
+	case "json":
+		return parseJSONFeedEntries(data)
+	default:
+		return nil, fmt.Errorf("неизвестный формат ленты: %s", format)
+	}
+}
+
+func detectFeedFormat(data []byte) string {
+	trimmed := bytes.TrimSpace(bytes.TrimPrefix(data, []byte("\xef\xbb\xbf")))
+	if len(trimmed) > 0 && trimmed[0] == '{' {
+		return "json"
+	}
+
+	decoder := newFeedDecoder(trimmed)
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return ""
+		}
+
+		if start, ok := token.(xml.StartElement); ok {
+			switch start.Name.Local {
+			case "rss":
+				return "rss"
+			case "feed":
+				return "atom"
+			case "RDF":
+				return "rdf"
+			default:
+				return ""
+			}
+		}
+	}
+}
+
+func newFeedDecoder(data []byte) *xml.Decoder {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.CharsetReader = charset.NewReaderLabel
+	decoder.Strict = false
+	decoder.Entity = xml.HTMLEntity
+	return decoder
+}
+
+func decodeFeedXML(data []byte, v any) error {
+	err := newFeedDecoder(data).Decode(v)
+	if err == io.EOF {
+		return fmt.Errorf("пустой документ")
+	}
+	return err
+}
+
+func parseRSSEntries(data []byte) ([]feedEntry, error) {
+	var feed rssFeed
+	if err := decodeFeedXML(data, &feed); err != nil {
+		return nil, fmt.Errorf("ошибка при разборе RSS: %w", err)
+	}
+
+	return convertRSSItems(feed.Channel.Items), nil
+}
+
+func parseRDFEntries(data []byte) ([]feedEntry, error) {
+	var feed rdfFeed
+	if err := decodeFeedXML(data, &feed); err != nil {
+		return nil, fmt.Errorf("ошибка при разборе RDF: %w", err)
+	}
+
+	return convertRSSItems(feed.Items), nil
+}
+
+func convertRSSItems(items []rssFeedItem) []feedEntry {
+	entries := make([]feedEntry, 0, len(items))
+
+	for _, item := range items {
+		entry := feedEntry{
+			Title:       item.Title,
+			Link:        item.Link,
+			GUID:        item.GUID,
+			Description: item.Description,
+			Content:     item.ContentEncoded,
+			PubDate:     item.PubDate,
+			Categories:  item.Categories,
+		}
+
+		if entry.PubDate == "" {
+			entry.PubDate = item.Date
+		}
+
+		for _, enclosure := range item.Enclosures {
+			entry.Enclosures = append(entry.Enclosures, feedEnclosure{URL: enclosure.URL, Type: enclosure.Type})
+		}
+
+		entry.MediaURLs = collectMediaURLs(item.MediaContent, item.MediaThumbnail)
+		entries = append(entries, entry)
+	}
+
+	return entries
+}
+
+func parseAtomEntries(data []byte) ([]feedEntry, error) {
+	var feed atomFeed
+	if err := decodeFeedXML(data, &feed); err != nil {
+		return nil, fmt.Errorf("ошибка при разборе Atom: %w", err)
+	}
+
+	entries := make([]feedEntry, 0, len(feed.Entries))
+
+	for _, item := range feed.Entries {
+		entry := feedEntry{
+			Title:       item.Title,
+			GUID:        item.ID,
+			Description: item.Summary.String(),
+			Content:     item.Content.String(),
+			PubDate:     item.Published,
+		}
+
+		if entry.PubDate == "" {
+			entry.PubDate = item.Updated
+		}
+
+		for _, link := range item.Links {
+			switch link.Rel {
+			case "", "alternate":
+				if entry.Link == "" {
+					entry.Link = link.Href
+				}
+			case "enclosure":
+				entry.Enclosures = append(entry.Enclosures, feedEnclosure{URL: link.Href, Type: link.Type})
+			}
+		}
+
+		for _, category := range item.Categories {
+			if category.Label != "" {
+				entry.Categories = append(entry.Categories, category.Label)
+			} else if category.Term != "" {
+				entry.Categories = append(entry.Categories, category.Term)
+			}
+		}
+
+		entry.MediaURLs = collectMediaURLs(item.MediaContent, item.MediaThumbnail)
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+func parseJSONFeedEntries(data []byte) ([]feedEntry, error) {
+	var feed jsonFeed
+	if err := json.Unmarshal(data, &feed); err != nil {
+		return nil, fmt.Errorf("ошибка при разборе JSON Feed: %w", err)
+	}
+
+	entries := make([]feedEntry, 0, len(feed.Items))
+
+	for _, item := range feed.Items {
+		entry := feedEntry{
+			Title:       item.Title,
+			Link:        item.URL,
+			GUID:        item.ID,
+			Description: item.Summary,
+			Content:     item.ContentHTML,
+			PubDate:     item.DatePublished,
+			Categories:  item.Tags,
+			Image:       item.Image,
+		}
+
+		if entry.Link == "" {
+			entry.Link = item.ExternalURL
+		}
+		if entry.Content == "" {
+			entry.Content = item.ContentText
+		}
+		if entry.Image == "" {
+			entry.Image = item.BannerImage
+		}
+
+		for _, attachment := range item.Attachments {
+			entry.Enclosures = append(entry.Enclosures, feedEnclosure{URL: attachment.URL, Type: attachment.MimeType})
+		}
+
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+func collectMediaURLs(groups ...[]feedMedia) []string {
+	var urls []string
+
+	for _, group := range groups {
+		for _, media := range group {
+			if media.URL == "" {
+				continue
+			}
+			if media.Medium != "" && media.Medium != "image" {
+				continue
+			}
+			if media.Type != "" && !strings.HasPrefix(media.Type, "image/") {
+				continue
+			}
+			urls = append(urls, media.URL)
+		}
+	}
+
+	return urls
+}
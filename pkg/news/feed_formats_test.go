@@ -0,0 +1,195 @@
+package news
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseFeedEntries(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   string
+		format string
+		want   []feedEntry
+	}{
+		{
+			name: "rss",
+			data: `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:media="http://search.yahoo.com/mrss/" xmlns:content="http://purl.org/rss/1.0/modules/content/">
+<channel>
+	<item>
+		<title>Первая новость</title>
+		<link>https://example.com/news/1</link>
+		<guid>news-1</guid>
+		<description>Кратко &amp; по делу</description>
+		<content:encoded><![CDATA[<p>Полный текст</p>]]></content:encoded>
+		<pubDate>Mon, 02 Jan 2006 15:04:05 +0300</pubDate>
+		<category>Игры</category>
+		<category>Железо</category>
+		<enclosure url="https://example.com/1.jpg" type="image/jpeg"/>
+		<media:content url="https://example.com/video.mp4" medium="video"/>
+		<media:thumbnail url="https://example.com/thumb.jpg"/>
+	</item>
+</channel>
+</rss>`,
+			want: []feedEntry{{
+				Title:       "Первая новость",
+				Link:        "https://example.com/news/1",
+				GUID:        "news-1",
+				Description: "Кратко & по делу",
+				Content:     "<p>Полный текст</p>",
+				PubDate:     "Mon, 02 Jan 2006 15:04:05 +0300",
+				Categories:  []string{"Игры", "Железо"},
+				Enclosures:  []feedEnclosure{{URL: "https://example.com/1.jpg", Type: "image/jpeg"}},
+				MediaURLs:   []string{"https://example.com/thumb.jpg"},
+			}},
+		},
+		{
+			name: "rss в windows-1251",
+			data: "<?xml version=\"1.0\" encoding=\"windows-1251\"?>\n<rss><channel><item><title>\xcd\xee\xe2\xee\xf1\xf2\xfc</title><link>https://example.com/2</link></item></channel></rss>",
+			want: []feedEntry{{
+				Title: "Новость",
+				Link:  "https://example.com/2",
+			}},
+		},
+		{
+			name: "atom",
+			data: `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+	<entry>
+		<title type="html">Atom &lt;b&gt;запись&lt;/b&gt;</title>
+		<id>tag:example.com,2024:1</id>
+		<link rel="enclosure" href="https://example.com/a.png" type="image/png"/>
+		<link rel="alternate" href="https://example.com/atom/1"/>
+		<link rel="alternate" href="https://example.com/atom/1?alt"/>
+		<summary>Анонс</summary>
+		<content type="html">&lt;p&gt;Текст&lt;/p&gt;</content>
+		<updated>2024-05-01T10:00:00Z</updated>
+		<category term="news" label="Новости"/>
+		<category term="tech"/>
+	</entry>
+</feed>`,
+			want: []feedEntry{{
+				Title:       "Atom <b>запись</b>",
+				Link:        "https://example.com/atom/1",
+				GUID:        "tag:example.com,2024:1",
+				Description: "Анонс",
+				Content:     "<p>Текст</p>",
+				PubDate:     "2024-05-01T10:00:00Z",
+				Categories:  []string{"Новости", "tech"},
+				Enclosures:  []feedEnclosure{{URL: "https://example.com/a.png", Type: "image/png"}},
+			}},
+		},
+		{
+			name: "rdf",
+			data: `<?xml version="1.0"?>
+<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#" xmlns="http://purl.org/rss/1.0/" xmlns:dc="http://purl.org/dc/elements/1.1/">
+	<channel><title>Лента</title></channel>
+	<item>
+		<title>RDF запись</title>
+		<link>https://example.com/rdf/1</link>
+		<description>Описание</description>
+		<dc:date>2024-05-01T10:00:00Z</dc:date>
+	</item>
+</rdf:RDF>`,
+			want: []feedEntry{{
+				Title:       "RDF запись",
+				Link:        "https://example.com/rdf/1",
+				Description: "Описание",
+				PubDate:     "2024-05-01T10:00:00Z",
+			}},
+		},
+		{
+			name: "json feed",
+			data: `{
+	"version": "https://jsonfeed.org/version/1.1",
+	"items": [{
+		"id": "42",
+		"external_url": "https://example.com/json/42",
+		"title": "JSON запись",
+		"content_text": "Текст",
+		"summary": "Анонс",
+		"banner_image": "https://example.com/banner.jpg",
+		"date_published": "2024-05-01T10:00:00Z",
+		"tags": ["игры"],
+		"attachments": [{"url": "https://example.com/file.png", "mime_type": "image/png"}]
+	}]
+}`,
+			want: []feedEntry{{
+				Title:       "JSON запись",
+				Link:        "https://example.com/json/42",
+				GUID:        "42",
+				Description: "Анонс",
+				Content:     "Текст",
+				PubDate:     "2024-05-01T10:00:00Z",
+				Categories:  []string{"игры"},
+				Enclosures:  []feedEnclosure{{URL: "https://example.com/file.png", Type: "image/png"}},
+				Image:       "https://example.com/banner.jpg",
+			}},
+		},
+		{
+			name:   "формат из настроек важнее определения",
+			data:   `<rss><channel><item><title>Запись</title></item></channel></rss>`,
+			format: "rss",
+			want:   []feedEntry{{Title: "Запись"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseFeedEntries([]byte(tt.data), tt.format)
+			if err != nil {
+				t.Fatalf("parseFeedEntries() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseFeedEntries() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFeedEntriesErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		data   string
+		format string
+	}{
+		{name: "неизвестный корневой элемент", data: `<html><body></body></html>`},
+		{name: "пустой документ", data: ``},
+		{name: "пустой документ с явным форматом", data: ``, format: "rss"},
+		{name: "битый json", data: `{"items": [`},
+		{name: "неизвестный формат в настройках", data: `<rss/>`, format: "yaml"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := parseFeedEntries([]byte(tt.data), tt.format); err == nil {
+				t.Error("parseFeedEntries() error = nil, want error")
+			}
+		})
+	}
+}
+
+func TestDetectFeedFormat(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{name: "rss", data: `<?xml version="1.0"?><rss version="2.0"></rss>`, want: "rss"},
+		{name: "atom", data: `<feed xmlns="http://www.w3.org/2005/Atom"></feed>`, want: "atom"},
+		{name: "rdf", data: `<rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#"></rdf:RDF>`, want: "rdf"},
+		{name: "json с BOM и пробелами", data: "\xef\xbb\xbf  {\"items\": []}", want: "json"},
+		{name: "комментарий перед корнем", data: `<!-- лента --><rss></rss>`, want: "rss"},
+		{name: "html", data: `<html></html>`, want: ""},
+		{name: "пусто", data: ``, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := detectFeedFormat([]byte(tt.data)); got != tt.want {
+				t.Errorf("detectFeedFormat() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package news
+
+import "testing"
+
+func TestMakeUniqueID(t *testing.T) {
+	const link = "https://example.com/news/2024/some-article.html"
+
+	tests := []struct {
+		name     string
+		strategy string
+		prefix   string
+		part     int
+		guid     string
+		link     string
+		want     string
+	}{
+		{name: "hash по guid", strategy: "hash", guid: "guid-1", link: link, want: hashString("guid-1")},
+		{name: "hash без guid берет ссылку", strategy: "hash", link: link, want: hashString(link)},
+		{name: "пустая стратегия работает как hash", strategy: "", guid: "guid-1", link: link, want: hashString("guid-1")},
+		{name: "hash_link игнорирует guid", strategy: "hash_link", guid: "guid-1", link: link, want: hashString(link)},
+		{name: "guid", strategy: "guid", guid: " guid-1 ", link: link, want: "guid-1"},
+		{name: "guid без guid берет ссылку", strategy: "guid", link: link, want: link},
+		{name: "link", strategy: "link", guid: "guid-1", link: " " + link + " ", want: link},
+		{name: "last_part без расширения", strategy: "last_part", link: link, want: "some-article"},
+		{name: "last_part со слешем в конце", strategy: "last_part", link: "https://example.com/news/12345/", want: "12345"},
+		{name: "part", strategy: "part", part: 4, link: link, want: "2024"},
+		{name: "part за пределами ссылки", strategy: "part", part: 10, link: link, want: link},
+		{name: "part с пустым сегментом", strategy: "part", part: 1, link: link, want: link},
+		{name: "part с отрицательным номером", strategy: "part", part: -1, link: link, want: link},
+		{name: "префикс", strategy: "link", prefix: "site_", link: link, want: "site_" + link},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := makeUniqueID(tt.strategy, tt.prefix, tt.part, tt.guid, tt.link); got != tt.want {
+				t.Errorf("makeUniqueID() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsValidUniqueIDStrategy(t *testing.T) {
+	for _, strategy := range []string{"", "hash", "hash_link", "guid", "link", "last_part", "part"} {
+		if !isValidUniqueIDStrategy(strategy) {
+			t.Errorf("isValidUniqueIDStrategy(%q) = false, want true", strategy)
+		}
+	}
+
+	for _, strategy := range []string{"md5", "GUID", "path"} {
+		if isValidUniqueIDStrategy(strategy) {
+			t.Errorf("isValidUniqueIDStrategy(%q) = true, want false", strategy)
+		}
+	}
+}
@@ -1,11 +1,12 @@
 package news
 
 import (
+	"context"
 	"encoding/xml"
 	"fmt"
 	"go-nelson/pkg/structures"
 	"go-nelson/pkg/utils"
-	"log"
+	"log/slog"
 	"strings"
 
 	"golang.org/x/net/html/charset"
@@ -32,12 +33,21 @@ type GameDevItem struct {
 	GUID        string `xml:"guid"`
 }
 
-func ParseGameDev() ([]structures.News, error) {
-	log.Println("Парсинг новостей с GameDev.ru")
+func init() {
+	RegisterSource(&parserSource{
+		name:     "gamedevru",
+		provider: "GameDev.ru",
+		tag:      "GameDev",
+		parse:    ParseGameDev,
+	})
+}
+
+func ParseGameDev(ctx context.Context) ([]structures.News, error) {
+	slog.Debug("Parsing source", "provider", "GameDev.ru")
 	var news []structures.News
-	fetcher := utils.NewFetcher()
+	fetcher := utils.NewConditionalFetcher()
 
-	data, err := fetcher.Fetch("https://gamedev.ru/rss")
+	data, err := fetcher.FetchContext(ctx, "https://gamedev.ru/rss")
 	if err != nil {
 		return nil, fmt.Errorf("ошибка при получении RSS ленты GameDev: %w", err)
 	}
@@ -60,7 +70,7 @@ func ParseGameDev() ([]structures.News, error) {
 
 		_, err := utils.ParseRSSDate(item.PubDate)
 		if err != nil {
-			log.Printf("Ошибка при парсинге даты публикации GameDev: %v", err)
+			slog.Warn("Failed to parse publication date", "provider", "GameDev.ru", "error", err)
 		}
 
 		imageURL := utils.ExtractImageURL(item.Description)
@@ -1,25 +1,35 @@
 package news
 
 import (
+	"context"
 	"crypto/md5"
 	"encoding/hex"
 	"fmt"
 	"go-nelson/pkg/structures"
 	"go-nelson/pkg/utils"
-	"log"
+	"log/slog"
 	"strings"
 
 	"github.com/PuerkitoBio/goquery"
 )
 
-func ParseIXBTGames() ([]structures.News, error) {
-	log.Println("Парсинг новостей с Ixbt Games")
+func init() {
+	RegisterSource(&parserSource{
+		name:     "ixbt",
+		provider: "Ixbt Games",
+		tag:      "Ixbt",
+		parse:    ParseIXBTGames,
+	})
+}
+
+func ParseIXBTGames(ctx context.Context) ([]structures.News, error) {
+	slog.Debug("Parsing source", "provider", "Ixbt Games")
 	var news []structures.News
-	fetcher := utils.NewFetcher()
+	fetcher := utils.NewConditionalFetcher()
 
-	data, err := fetcher.Fetch("https://ixbt.games/news/")
+	data, err := fetcher.FetchContext(ctx, "https://ixbt.games/news/")
 	if err != nil {
-		return nil, fmt.Errorf("ошибка при запросе страницы Ixbt Games: %v", err)
+		return nil, fmt.Errorf("ошибка при запросе страницы Ixbt Games: %w", err)
 	}
 
 	doc, err := goquery.NewDocumentFromReader(strings.NewReader(string(data)))
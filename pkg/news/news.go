@@ -1,116 +1,114 @@
 package news
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"go-nelson/pkg"
 	"go-nelson/pkg/db"
 	"go-nelson/pkg/services"
 	"go-nelson/pkg/structures"
-	"log"
+	"go-nelson/pkg/utils"
+	"log/slog"
+	"sync"
 	"time"
 )
 
-func StartNewsParser() {
-	log.Println("Запуск парсера новостей")
-	parseAllSources()
+// StartNewsParser блокируется до отмены ctx и возвращается после обработки уже полученных новостей
+func StartNewsParser(ctx context.Context) error {
+	slog.Info("Starting news parser")
 
-	ticker := time.NewTicker(60 * time.Minute)
-	defer ticker.Stop()
-
-	for range ticker.C {
-		parseAllSources()
+	pool, err := newSourcePool()
+	if err != nil {
+		return fmt.Errorf("некорректные настройки планировщика: %w", err)
 	}
-}
+	pool.start(ctx)
 
-func parseAllSources() {
-	log.Println("Парсинг всех источников новостей")
-	var allNews []structures.News
+	statusMu.Lock()
+	activePool = pool
+	statusMu.Unlock()
 
-	if pkg.Parsers.Ixbt {
-		ixbtGamesNews, err := ParseIXBTGames()
+	var wg sync.WaitGroup
+	for _, source := range EnabledSources() {
+		schedule, err := newSourceSchedule(pkg.Parsers[source.Name()])
 		if err != nil {
-			log.Printf("Ошибка при парсинге IXBT Games: %v", err)
-		} else {
-			allNews = append(allNews, ixbtGamesNews...)
+			slog.Error("Invalid source schedule", "source", source.Name(), "error", err)
+			continue
 		}
-	}
 
-	if pkg.Parsers.Stopgame {
-		stopGameNews, err := ParseStopGame()
-		if err != nil {
-			log.Printf("Ошибка при парсинге StopGame: %v", err)
-		} else {
-			allNews = append(allNews, stopGameNews...)
+		if err := validateRetraction(pkg.Parsers[source.Name()].Retraction); err != nil {
+			slog.Error("Invalid source retraction config", "source", source.Name(), "error", err)
 		}
-	}
 
-	if pkg.Parsers.DTF {
-		dtfNews, err := ParseDTF()
-		if err != nil {
-			log.Printf("Ошибка при парсинге DTF: %v", err)
-		} else {
-			allNews = append(allNews, dtfNews...)
+		if profile := pkg.Parsers[source.Name()].HTTPProfile; profile != "" && !utils.HasHTTPProfile(profile) {
+			slog.Warn("Source uses unknown HTTP profile", "source", source.Name(), "profile", profile)
 		}
-	}
 
-	if pkg.Parsers.DisgustingMen {
-		disgustingmenNews, err := ParseDMen()
-		if err != nil {
-			log.Printf("Ошибка при парсинге DisgustingMen: %v", err)
-		} else {
-			allNews = append(allNews, disgustingmenNews...)
-		}
+		wg.Add(1)
+		go func(source Source, schedule *sourceSchedule) {
+			defer wg.Done()
+			runSourceSchedule(ctx, pool, source, schedule)
+		}(source, schedule)
 	}
 
-	if pkg.Parsers.ThreeDNews {
-		threedsNews, err := Parse3DNews()
-		if err != nil {
-			log.Printf("Ошибка при парсинге 3DNews: %v", err)
-		} else {
-			allNews = append(allNews, threedsNews...)
-		}
+	wg.Wait()
+	if ctx.Err() == nil {
+		slog.Warn("No active news sources scheduled")
+		<-ctx.Done()
 	}
 
-	if pkg.Parsers.EpicGames {
-		epicGamesNews, err := ParseEpicGamesStore()
-		if err != nil {
-			log.Printf("Ошибка при парсинге EpicGames: %v", err)
-		} else {
-			allNews = append(allNews, epicGamesNews...)
+	pool.wait()
+	slog.Info("News parser stopped")
+	return nil
+}
+
+func runSourceSchedule(ctx context.Context, pool *sourcePool, source Source, schedule *sourceSchedule) {
+	slog.Info("Source scheduled", "provider", source.Provider(), "schedule", schedule.String())
+	pool.submit(source)
+
+	for {
+		next := schedule.next(time.Now())
+		updateSourceStatus(source, func(status *SourceStatus) {
+			status.NextRunAt = next
+		})
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
 		}
+		pool.submit(source)
 	}
+}
 
-	if pkg.Parsers.GamedevRu {
-		gamedevNews, err := ParseGameDev()
-		if err != nil {
-			log.Printf("Ошибка при парсинге GameDev: %v", err)
-		} else {
-			allNews = append(allNews, gamedevNews...)
+func assignLanguage(source Source, news []structures.News) {
+	language := pkg.Parsers[source.Name()].Language
+
+	for i := range news {
+		if news[i].Language != "" {
+			continue
 		}
-	}
 
-	if pkg.Parsers.SteamDevelopers {
-		steamNews, err := ParseSteam()
-		if err != nil {
-			log.Printf("Ошибка при парсинге Steam Developer: %v", err)
+		if language != "" {
+			news[i].Language = language
 		} else {
-			allNews = append(allNews, steamNews...)
+			news[i].Language = utils.DetectLanguage(news[i].Title + " " + news[i].Description)
 		}
 	}
-
-	filteredNews := filterExistingNews(allNews)
-
-	if len(filteredNews) > 0 {
-		processNews(filteredNews)
-	}
 }
 
-func filterExistingNews(allNews []structures.News) []structures.News {
+// Ошибка возвращается вместе с частичным результатом, чтобы новости остальных провайдеров не терялись
+func filterExistingNews(allNews []structures.News) ([]structures.News, []structures.News, error) {
 	if len(allNews) == 0 {
-		return []structures.News{}
+		return []structures.News{}, []structures.News{}, nil
 	}
 
 	newsRepo := db.NewNewsRepository()
 	var filteredNews []structures.News
+	var changedNews []structures.News
+	var errs []error
 
 	newsByProvider := make(map[string][]structures.News)
 	for _, n := range allNews {
@@ -123,32 +121,85 @@ func filterExistingNews(allNews []structures.News) []structures.News {
 			uniqueIDs = append(uniqueIDs, n.UniqueID)
 		}
 
-		existingIDs, err := newsRepo.FindNewsByProviderAndUniqueIDs(provider, uniqueIDs)
+		existingNews, err := newsRepo.FindByProviderAndUniqueIDs(provider, uniqueIDs)
 		if err != nil {
-			log.Printf("Ошибка при проверке существующих новостей для %s: %v", provider, err)
+			slog.Error("Failed to check existing news", "provider", provider, "error", err)
+			errs = append(errs, err)
 			continue
 		}
 
 		for _, n := range news {
-			if !existingIDs[n.UniqueID] {
+			existing, ok := existingNews[n.UniqueID]
+			if !ok {
 				filteredNews = append(filteredNews, n)
+				continue
+			}
+
+			if isNewsChanged(existing, n) {
+				changedNews = append(changedNews, n)
 			}
 		}
 	}
 
-	return filteredNews
+	return filteredNews, changedNews, errors.Join(errs...)
 }
 
-func processNews(news []structures.News) {
-	log.Printf("Обработка %d новых новостей", len(news))
+func isNewsChanged(existing *structures.News, fresh structures.News) bool {
+	if existing.Title != fresh.Title || existing.Description != fresh.Description {
+		return true
+	}
+
+	var existingImage, freshImage string
+	if len(existing.Images) > 0 {
+		existingImage = existing.Images[0]
+	}
+	if len(fresh.Images) > 0 {
+		freshImage = fresh.Images[0]
+	}
+
+	return existingImage != freshImage
+}
+
+func processNews(news []structures.News) error {
+	slog.Info("Processing new news", "count", len(news))
+	newsRepo := db.NewNewsRepository()
+
+	var savedNews []structures.News
+	var errs []error
+	for i := range news {
+		// Флаг снимается после постановки в очередь, а оставшиеся с ним новости ставятся при следующем запуске
+		news[i].DeliveryPending = true
+
+		err := newsRepo.Save(&news[i])
+		if err != nil {
+			slog.Error("Failed to save news", "provider", news[i].Provider, "title", news[i].Title, "error", err)
+			errs = append(errs, err)
+			continue
+		}
+		savedNews = append(savedNews, news[i])
+	}
+
+	services.SendNews(savedNews)
+	services.NotifyWatchers(savedNews)
+	return errors.Join(errs...)
+}
+
+func processChangedNews(news []structures.News) error {
+	slog.Info("Processing changed news", "count", len(news))
 	newsRepo := db.NewNewsRepository()
 
-	for _, n := range news {
-		err := newsRepo.Save(&n)
+	var savedNews []structures.News
+	var errs []error
+	for i := range news {
+		err := newsRepo.Save(&news[i])
 		if err != nil {
-			log.Printf("Ошибка при сохранении новости: %v", err)
+			slog.Error("Failed to update news", "provider", news[i].Provider, "news_id", news[i].Id.Hex(), "title", news[i].Title, "error", err)
+			errs = append(errs, err)
+			continue
 		}
+		savedNews = append(savedNews, news[i])
 	}
 
-	go services.SendNews(news)
+	services.UpdateNews(savedNews)
+	return errors.Join(errs...)
 }
@@ -0,0 +1,245 @@
+package news
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-nelson/pkg"
+	"go-nelson/pkg/metrics"
+	"go-nelson/pkg/structures"
+	"go-nelson/pkg/utils"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+const (
+	defaultPoolWorkers = 4
+	defaultRunTimeout  = 2 * time.Minute
+)
+
+type sourceJob struct {
+	source Source
+}
+
+type sourceResult struct {
+	source   Source
+	news     []structures.News
+	err      error
+	duration time.Duration
+	commit   func()
+}
+
+type sourcePool struct {
+	ctx     context.Context
+	jobs    chan sourceJob
+	results chan sourceResult
+	workers int
+	timeout time.Duration
+
+	activeMu sync.Mutex
+	active   map[string]bool
+
+	workersWG sync.WaitGroup
+	done      chan struct{}
+}
+
+func newSourcePool() (*sourcePool, error) {
+	workers := pkg.Scheduler.Workers
+	if workers <= 0 {
+		workers = defaultPoolWorkers
+	}
+
+	timeout := defaultRunTimeout
+	if pkg.Scheduler.RunTimeout != "" {
+		parsed, err := time.ParseDuration(pkg.Scheduler.RunTimeout)
+		if err != nil {
+			return nil, fmt.Errorf("некорректный таймаут запуска '%s': %v", pkg.Scheduler.RunTimeout, err)
+		}
+		if parsed <= 0 {
+			return nil, fmt.Errorf("таймаут запуска должен быть больше нуля")
+		}
+		timeout = parsed
+	}
+
+	return &sourcePool{
+		jobs:    make(chan sourceJob),
+		results: make(chan sourceResult, workers),
+		workers: workers,
+		timeout: timeout,
+		active:  make(map[string]bool),
+		done:    make(chan struct{}),
+	}, nil
+}
+
+// После отмены ctx текущие запуски прерываются, а уже полученные результаты сохраняются до закрытия пула
+func (p *sourcePool) start(ctx context.Context) {
+	slog.Info("Starting parser pool", "workers", p.workers, "run_timeout", p.timeout)
+	p.ctx = ctx
+
+	for i := 0; i < p.workers; i++ {
+		p.workersWG.Add(1)
+		go p.worker()
+	}
+
+	go func() {
+		p.workersWG.Wait()
+		close(p.results)
+	}()
+
+	go p.collect()
+}
+
+// wait возвращается, когда все воркеры остановлены и результаты обработаны
+func (p *sourcePool) wait() {
+	<-p.done
+}
+
+func (p *sourcePool) submit(source Source) {
+	if p.ctx.Err() != nil {
+		return
+	}
+
+	p.activeMu.Lock()
+	if p.active[source.Name()] {
+		p.activeMu.Unlock()
+		slog.Warn("Previous source run still in progress, run skipped", "provider", source.Provider())
+		return
+	}
+	p.active[source.Name()] = true
+	p.activeMu.Unlock()
+
+	updateSourceStatus(source, func(status *SourceStatus) {
+		status.Running = true
+	})
+
+	job := sourceJob{source: source}
+
+	select {
+	case p.jobs <- job:
+	case <-p.ctx.Done():
+		p.activeMu.Lock()
+		delete(p.active, source.Name())
+		p.activeMu.Unlock()
+
+		updateSourceStatus(source, func(status *SourceStatus) {
+			status.Running = false
+		})
+	}
+}
+
+func (p *sourcePool) worker() {
+	defer p.workersWG.Done()
+
+	for {
+		select {
+		case <-p.ctx.Done():
+			return
+		case job := <-p.jobs:
+			p.results <- p.run(job)
+		}
+	}
+}
+
+// Таймаут отсчитывается с момента, когда воркер взял задачу, а не с постановки в очередь,
+// чтобы ожидание свободного воркера не съедало время запуска
+func (p *sourcePool) run(job sourceJob) sourceResult {
+	ctx, cancel := context.WithTimeout(p.ctx, p.timeout)
+	defer cancel()
+
+	ctx, commit := utils.WithPendingValidators(ctx)
+	ctx = utils.WithHTTPProfile(ctx, pkg.Parsers[job.source.Name()].HTTPProfile)
+	ctx = metrics.WithSource(ctx, job.source.Name())
+
+	started := time.Now()
+	result := sourceResult{source: job.source, commit: commit}
+
+	result.news, result.err = job.source.Parse(ctx)
+	result.duration = time.Since(started)
+
+	// Результат успешного парсинга сохраняется, даже если таймаут истек сразу после его завершения
+	if result.err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		result.err = fmt.Errorf("истек таймаут запуска: %w", result.err)
+	}
+
+	return result
+}
+
+func (p *sourcePool) collect() {
+	defer close(p.done)
+
+	for result := range p.results {
+		p.activeMu.Lock()
+		delete(p.active, result.source.Name())
+		p.activeMu.Unlock()
+
+		updateSourceStatus(result.source, func(status *SourceStatus) {
+			status.Running = false
+			status.LastRunAt = time.Now()
+			status.LastDuration = result.duration
+			status.LastFound = 0
+			status.LastNew = 0
+		})
+
+		// Прерванный остановкой запуск не считается ошибкой источника и будет повторен после перезапуска
+		if result.err != nil && p.ctx.Err() != nil {
+			slog.Info("Source run interrupted by shutdown", "provider", result.source.Provider())
+			continue
+		}
+
+		metrics.SourceFetchDuration.WithLabelValues(result.source.Name()).Observe(result.duration.Seconds())
+
+		if errors.Is(result.err, utils.ErrNotModified) {
+			slog.Info("Source not modified since last run", "provider", result.source.Provider())
+			metrics.SourceRuns.WithLabelValues(result.source.Name(), "not_modified").Inc()
+			metrics.SourceLastSuccess.WithLabelValues(result.source.Name()).SetToCurrentTime()
+			continue
+		}
+
+		if result.err != nil {
+			slog.Error("Failed to parse source", "provider", result.source.Provider(), "error", result.err)
+			metrics.SourceRuns.WithLabelValues(result.source.Name(), "error").Inc()
+			metrics.SourceParseErrors.WithLabelValues(result.source.Name()).Inc()
+			updateSourceStatus(result.source, func(status *SourceStatus) {
+				status.LastError = result.err.Error()
+				status.LastErrorAt = time.Now()
+			})
+			continue
+		}
+
+		slog.Info("Source processed", "provider", result.source.Provider(),
+			"duration", result.duration.Round(time.Millisecond), "found", len(result.news))
+
+		assignLanguage(result.source, result.news)
+
+		filteredNews, changedNews, storageErr := filterExistingNews(result.news)
+
+		updateSourceStatus(result.source, func(status *SourceStatus) {
+			status.LastFound = len(result.news)
+			status.LastNew = len(filteredNews)
+		})
+
+		metrics.SourceRuns.WithLabelValues(result.source.Name(), "ok").Inc()
+		metrics.SourceLastSuccess.WithLabelValues(result.source.Name()).SetToCurrentTime()
+		metrics.SourceItemsFound.WithLabelValues(result.source.Name()).Add(float64(len(result.news)))
+		metrics.SourceNewItems.WithLabelValues(result.source.Name()).Add(float64(len(filteredNews)))
+
+		if len(filteredNews) > 0 {
+			storageErr = errors.Join(storageErr, processNews(filteredNews))
+		}
+
+		if len(changedNews) > 0 {
+			storageErr = errors.Join(storageErr, processChangedNews(changedNews))
+		}
+
+		detectRetractions(result.source, result.news)
+
+		// Если часть новостей не сохранилась, валидаторы не запоминаются: иначе следующий запуск
+		// получит 304 и несохраненные новости больше не будут загружены
+		if storageErr != nil {
+			slog.Warn("HTTP validators not saved because some news were not stored", "provider", result.source.Provider())
+			continue
+		}
+		result.commit()
+	}
+}
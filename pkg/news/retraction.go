@@ -0,0 +1,141 @@
+package news
+
+import (
+	"fmt"
+	"go-nelson/pkg"
+	"go-nelson/pkg/db"
+	"go-nelson/pkg/services"
+	"go-nelson/pkg/structures"
+	"log/slog"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	RetractionPolicyLog    = "log"
+	RetractionPolicyDelete = "delete"
+	RetractionPolicyMark   = "mark"
+
+	defaultRetractionWindow      = 24 * time.Hour
+	defaultRetractionMissingRuns = 3
+)
+
+func validateRetraction(config structures.RetractionConfigStruct) error {
+	switch config.Policy {
+	case "", RetractionPolicyLog, RetractionPolicyDelete, RetractionPolicyMark:
+	default:
+		return fmt.Errorf("неизвестная политика отзыва %q", config.Policy)
+	}
+
+	if config.Window != "" {
+		window, err := time.ParseDuration(config.Window)
+		if err != nil {
+			return fmt.Errorf("некорректное окно отзыва %q: %w", config.Window, err)
+		}
+		if window <= 0 {
+			return fmt.Errorf("окно отзыва должно быть положительным")
+		}
+	}
+
+	if config.MissingRuns < 0 {
+		return fmt.Errorf("число запусков до отзыва не может быть отрицательным")
+	}
+
+	return nil
+}
+
+// Новость считается удаленной источником, если ее нет в выдаче, хотя она опубликована позже самой
+// старой новости выдачи, и так подряд в missing_runs успешных запусках. Порядок определяется датой
+// публикации, а не временем сохранения: пакет сохраняется от новых к старым, поэтому время сохранения
+// не отражает положение в ленте. Для источников без даты публикации отзыв не определяется
+func detectRetractions(source Source, current []structures.News) {
+	config := pkg.Parsers[source.Name()].Retraction
+	if config.Policy == "" || len(current) == 0 {
+		return
+	}
+
+	window := defaultRetractionWindow
+	if config.Window != "" {
+		parsed, err := time.ParseDuration(config.Window)
+		if err != nil {
+			return
+		}
+		window = parsed
+	}
+
+	missingRuns := config.MissingRuns
+	if missingRuns <= 0 {
+		missingRuns = defaultRetractionMissingRuns
+	}
+
+	newsRepo := db.NewNewsRepository()
+
+	uniqueIDs := make([]string, 0, len(current))
+	var oldest time.Time
+	for _, n := range current {
+		uniqueIDs = append(uniqueIDs, n.UniqueID)
+		if !n.PublishedAt.IsZero() && (oldest.IsZero() || n.PublishedAt.Before(oldest)) {
+			oldest = n.PublishedAt
+		}
+	}
+
+	existing, err := newsRepo.FindByProviderAndUniqueIDs(source.Provider(), uniqueIDs)
+	if err != nil {
+		slog.Error("Failed to check retracted news", "provider", source.Provider(), "error", err)
+		return
+	}
+
+	// Новость, вернувшаяся в выдачу, начинает отсчет пропусков заново
+	var returned []primitive.ObjectID
+	for _, n := range existing {
+		if n.MissingRuns > 0 {
+			returned = append(returned, n.Id)
+		}
+	}
+	if len(returned) > 0 {
+		if err := newsRepo.ResetMissingRuns(returned); err != nil {
+			slog.Error("Failed to reset missing runs", "provider", source.Provider(), "error", err)
+		}
+	}
+
+	if oldest.IsZero() {
+		return
+	}
+
+	candidates, err := newsRepo.FindActiveSince(source.Provider(), time.Now().Add(-window))
+	if err != nil {
+		slog.Error("Failed to load stored news", "provider", source.Provider(), "error", err)
+		return
+	}
+
+	for _, candidate := range candidates {
+		if _, ok := existing[candidate.UniqueID]; ok || !candidate.PublishedAt.After(oldest) {
+			continue
+		}
+
+		runs, err := newsRepo.IncrementMissingRuns(candidate.Id)
+		if err != nil {
+			slog.Error("Failed to update missing runs", "provider", source.Provider(), "news_id", candidate.Id.Hex(), "error", err)
+			continue
+		}
+		if runs < missingRuns {
+			slog.Debug("News missing from source", "provider", source.Provider(), "news_id", candidate.Id.Hex(), "runs", runs, "threshold", missingRuns)
+			continue
+		}
+
+		if err := newsRepo.MarkRetracted(candidate.Id); err != nil {
+			slog.Error("Failed to mark news as retracted", "provider", source.Provider(), "news_id", candidate.Id.Hex(), "title", candidate.Title, "error", err)
+			continue
+		}
+
+		slog.Info("News removed by source", "provider", source.Provider(), "news_id", candidate.Id.Hex(), "title", candidate.Title, "url", candidate.URL)
+
+		switch config.Policy {
+		case RetractionPolicyDelete:
+			services.RetractNews(*candidate, structures.DeliveryActionDelete)
+		case RetractionPolicyMark:
+			services.RetractNews(*candidate, structures.DeliveryActionMark)
+		}
+	}
+}
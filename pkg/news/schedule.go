@@ -0,0 +1,94 @@
+package news
+
+import (
+	"fmt"
+	"go-nelson/pkg"
+	"go-nelson/pkg/structures"
+	"go-nelson/pkg/utils"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+const defaultSourceInterval = 60 * time.Minute
+
+type sourceSchedule struct {
+	interval time.Duration
+	cron     cron.Schedule
+	cronSpec string
+	jitter   time.Duration
+}
+
+func newSourceSchedule(config structures.SourceConfigStruct) (*sourceSchedule, error) {
+	schedule := &sourceSchedule{
+		interval: defaultSourceInterval,
+	}
+
+	if pkg.Scheduler.DefaultInterval != "" {
+		interval, err := time.ParseDuration(pkg.Scheduler.DefaultInterval)
+		if err != nil {
+			return nil, fmt.Errorf("некорректный интервал по умолчанию '%s': %v", pkg.Scheduler.DefaultInterval, err)
+		}
+		schedule.interval = interval
+	}
+
+	if config.Interval != "" {
+		interval, err := time.ParseDuration(config.Interval)
+		if err != nil {
+			return nil, fmt.Errorf("некорректный интервал '%s': %v", config.Interval, err)
+		}
+		schedule.interval = interval
+	}
+
+	if schedule.interval <= 0 {
+		return nil, fmt.Errorf("интервал должен быть больше нуля")
+	}
+
+	if config.Cron != "" {
+		cronSchedule, err := cron.ParseStandard(config.Cron)
+		if err != nil {
+			return nil, fmt.Errorf("некорректное cron-выражение '%s': %v", config.Cron, err)
+		}
+		schedule.cron = cronSchedule
+		schedule.cronSpec = config.Cron
+	}
+
+	if config.Jitter != "" {
+		jitter, err := time.ParseDuration(config.Jitter)
+		if err != nil {
+			return nil, fmt.Errorf("некорректный джиттер '%s': %v", config.Jitter, err)
+		}
+		if jitter < 0 {
+			return nil, fmt.Errorf("джиттер не может быть отрицательным")
+		}
+		schedule.jitter = jitter
+	}
+
+	return schedule, nil
+}
+
+func (s *sourceSchedule) next(from time.Time) time.Time {
+	var next time.Time
+	if s.cron != nil {
+		next = s.cron.Next(from)
+	} else {
+		next = from.Add(s.interval)
+	}
+
+	return next.Add(utils.RandomDuration(s.jitter))
+}
+
+func (s *sourceSchedule) String() string {
+	var description string
+	if s.cron != nil {
+		description = fmt.Sprintf("по расписанию '%s'", s.cronSpec)
+	} else {
+		description = fmt.Sprintf("каждые %s", s.interval)
+	}
+
+	if s.jitter > 0 {
+		description += fmt.Sprintf(" (джиттер до %s)", s.jitter)
+	}
+
+	return description
+}
@@ -0,0 +1,208 @@
+package news
+
+import (
+	"context"
+	"fmt"
+	"go-nelson/pkg/structures"
+	"go-nelson/pkg/utils"
+	"log/slog"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
+)
+
+type scraperSource struct {
+	config  structures.ScraperConfigStruct
+	baseURL *url.URL
+}
+
+func NewScraperSource(config structures.ScraperConfigStruct) (Source, error) {
+	if config.Name == "" {
+		return nil, fmt.Errorf("не указано имя скрапера")
+	}
+	if config.Provider == "" {
+		return nil, fmt.Errorf("не указан провайдер скрапера %s", config.Name)
+	}
+	if config.URL == "" {
+		return nil, fmt.Errorf("не указан URL скрапера %s", config.Name)
+	}
+	if config.ItemSelector == "" {
+		return nil, fmt.Errorf("не указан селектор элементов скрапера %s", config.Name)
+	}
+	if config.Title.Selector == "" && config.Title.Attr == "" {
+		return nil, fmt.Errorf("не указан селектор заголовка скрапера %s", config.Name)
+	}
+	if !isValidUniqueIDStrategy(config.UniqueID) {
+		return nil, fmt.Errorf("неизвестная стратегия уникального ID скрапера %s: %s", config.Name, config.UniqueID)
+	}
+
+	base := config.BaseURL
+	if base == "" {
+		base = config.URL
+	}
+
+	baseURL, err := url.Parse(base)
+	if err != nil {
+		return nil, fmt.Errorf("некорректный базовый URL скрапера %s: %v", config.Name, err)
+	}
+
+	for _, selector := range []string{config.ItemSelector, config.Title.Selector, config.Link.Selector,
+		config.Description.Selector, config.Image.Selector, config.Date.Selector} {
+		if selector == "" {
+			continue
+		}
+		if _, err := cascadia.Compile(selector); err != nil {
+			return nil, fmt.Errorf("некорректный селектор скрапера %s '%s': %v", config.Name, selector, err)
+		}
+	}
+
+	return &scraperSource{config: config, baseURL: baseURL}, nil
+}
+
+func (s *scraperSource) Name() string {
+	return s.config.Name
+}
+
+func (s *scraperSource) Provider() string {
+	return s.config.Provider
+}
+
+func (s *scraperSource) Tag() string {
+	return s.config.Tag
+}
+
+func (s *scraperSource) Parse(ctx context.Context) ([]structures.News, error) {
+	slog.Debug("Parsing source", "provider", s.config.Provider)
+	var news []structures.News
+	fetcher := utils.NewConditionalFetcher()
+
+	data, err := fetcher.FetchContext(ctx, s.config.URL)
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при запросе страницы %s: %w", s.config.Provider, err)
+	}
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("ошибка при парсинге HTML %s: %w", s.config.Provider, err)
+	}
+
+	items := doc.Find(s.config.ItemSelector)
+	if items.Length() == 0 {
+		return nil, fmt.Errorf("селектор '%s' не нашел элементов на странице %s", s.config.ItemSelector, s.config.Provider)
+	}
+
+	items.Each(func(i int, item *goquery.Selection) {
+		title := extractSelector(item, s.config.Title, "text")
+		if title == "" {
+			return
+		}
+
+		link := s.resolveURL(extractSelector(item, s.config.Link, "href"))
+		if link == "" {
+			return
+		}
+
+		var description string
+		if s.config.Description.Selector != "" || s.config.Description.Attr != "" {
+			description = applyDescriptionRule(s.config.DescriptionRule, extractSelector(item, s.config.Description, "text"))
+		}
+		if description == "" && s.config.DescriptionRule.SkipEmpty {
+			return
+		}
+
+		var images []string
+		imageURL := s.config.DefaultImage
+		if s.config.Image.Selector != "" || s.config.Image.Attr != "" {
+			if found := s.resolveURL(extractSelector(item, s.config.Image, "src")); found != "" {
+				imageURL = found
+			}
+		}
+		if imageURL != "" {
+			images = append(images, imageURL)
+		}
+
+		var tags []string
+		tags = append(tags, s.config.Tags...)
+
+		newsItem := structures.News{
+			Provider:    s.config.Provider,
+			UniqueID:    makeUniqueID(s.config.UniqueID, s.config.UniqueIDPrefix, s.config.UniqueIDPart, "", link),
+			Title:       title,
+			Description: description,
+			URL:         link,
+			Images:      images,
+			Tags:        tags,
+		}
+
+		if s.config.Date.Selector != "" || s.config.Date.Attr != "" {
+			publishedAt, err := s.parseDate(extractSelector(item, s.config.Date, "text"))
+			if err != nil {
+				slog.Warn("Failed to parse publication date", "provider", s.config.Provider, "error", err)
+			} else {
+				newsItem.PublishedAt = publishedAt
+			}
+		}
+
+		news = append(news, newsItem)
+	})
+
+	return news, nil
+}
+
+func (s *scraperSource) resolveURL(raw string) string {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return ""
+	}
+
+	ref, err := url.Parse(raw)
+	if err != nil {
+		return ""
+	}
+
+	return s.baseURL.ResolveReference(ref).String()
+}
+
+func (s *scraperSource) parseDate(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, fmt.Errorf("пустая дата")
+	}
+
+	if s.config.DateLayout != "" {
+		return time.Parse(s.config.DateLayout, value)
+	}
+
+	return utils.ParseRSSDate(value)
+}
+
+func extractSelector(item *goquery.Selection, rule structures.SelectorRuleStruct, defaultAttr string) string {
+	selection := item
+	if rule.Selector != "" {
+		selection = item.Find(rule.Selector).First()
+	}
+	if selection.Length() == 0 {
+		return ""
+	}
+
+	attr := rule.Attr
+	if attr == "" {
+		attr = defaultAttr
+	}
+
+	switch attr {
+	case "text":
+		return strings.TrimSpace(selection.Text())
+	case "html":
+		html, err := selection.Html()
+		if err != nil {
+			return ""
+		}
+		return strings.TrimSpace(html)
+	default:
+		value, _ := selection.Attr(attr)
+		return strings.TrimSpace(value)
+	}
+}
@@ -0,0 +1,100 @@
+package news
+
+import (
+	"context"
+	"go-nelson/pkg"
+	"go-nelson/pkg/services"
+	"go-nelson/pkg/structures"
+	"log/slog"
+	"sort"
+	"sync"
+)
+
+type Source interface {
+	Name() string
+	Provider() string
+	Tag() string
+	Parse(ctx context.Context) ([]structures.News, error)
+}
+
+type parserSource struct {
+	name     string
+	provider string
+	tag      string
+	parse    func(ctx context.Context) ([]structures.News, error)
+}
+
+func (s *parserSource) Name() string {
+	return s.name
+}
+
+func (s *parserSource) Provider() string {
+	return s.provider
+}
+
+func (s *parserSource) Tag() string {
+	return s.tag
+}
+
+func (s *parserSource) Parse(ctx context.Context) ([]structures.News, error) {
+	return s.parse(ctx)
+}
+
+var (
+	sourcesMu sync.RWMutex
+	sources   = make(map[string]Source)
+)
+
+func RegisterSource(source Source) {
+	sourcesMu.Lock()
+	defer sourcesMu.Unlock()
+
+	if _, exists := sources[source.Name()]; exists {
+		slog.Warn("Source already registered, registration overwritten", "source", source.Name())
+	}
+	sources[source.Name()] = source
+
+	services.RegisterProvider(source.Name(), source.Provider(), source.Tag())
+}
+
+func GetSource(name string) (Source, bool) {
+	sourcesMu.RLock()
+	defer sourcesMu.RUnlock()
+
+	source, ok := sources[name]
+	return source, ok
+}
+
+func Sources() []Source {
+	sourcesMu.RLock()
+	defer sourcesMu.RUnlock()
+
+	result := make([]Source, 0, len(sources))
+	for _, source := range sources {
+		result = append(result, source)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name() < result[j].Name()
+	})
+
+	return result
+}
+
+func EnabledSources() []Source {
+	var enabled []Source
+
+	for name, config := range pkg.Parsers {
+		if _, ok := GetSource(name); !ok && config.Enabled {
+			slog.Warn("Source configured but not registered", "source", name)
+		}
+	}
+
+	for _, source := range Sources() {
+		if pkg.Parsers[source.Name()].Enabled {
+			enabled = append(enabled, source)
+		}
+	}
+
+	return enabled
+}
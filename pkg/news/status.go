@@ -0,0 +1,85 @@
+package news
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Состояние хранится в памяти и сбрасывается при перезапуске, история запусков есть в логах
+type SourceStatus struct {
+	Name         string
+	Provider     string
+	Running      bool
+	LastRunAt    time.Time
+	LastDuration time.Duration
+	LastFound    int
+	LastNew      int
+	LastError    string
+	LastErrorAt  time.Time
+	NextRunAt    time.Time
+}
+
+var (
+	statusMu   sync.RWMutex
+	statuses   = make(map[string]*SourceStatus)
+	activePool *sourcePool
+)
+
+func sourceStatus(source Source) *SourceStatus {
+	status, ok := statuses[source.Name()]
+	if !ok {
+		status = &SourceStatus{Name: source.Name(), Provider: source.Provider()}
+		statuses[source.Name()] = status
+	}
+	return status
+}
+
+func updateSourceStatus(source Source, update func(status *SourceStatus)) {
+	statusMu.Lock()
+	defer statusMu.Unlock()
+
+	update(sourceStatus(source))
+}
+
+// SourceStatuses возвращает состояние всех включенных источников, отсортированных по имени
+func SourceStatuses() []SourceStatus {
+	enabled := EnabledSources()
+
+	statusMu.RLock()
+	defer statusMu.RUnlock()
+
+	result := make([]SourceStatus, 0, len(enabled))
+	for _, source := range enabled {
+		if status, ok := statuses[source.Name()]; ok {
+			result = append(result, *status)
+		} else {
+			result = append(result, SourceStatus{Name: source.Name(), Provider: source.Provider()})
+		}
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+// TriggerSource ставит внеочередной запуск источника, не сдвигая его расписание
+func TriggerSource(name string) error {
+	source, ok := GetSource(name)
+	if !ok {
+		return fmt.Errorf("источник %s не найден", name)
+	}
+
+	statusMu.RLock()
+	pool := activePool
+	statusMu.RUnlock()
+
+	if pool == nil {
+		return fmt.Errorf("парсер новостей не запущен")
+	}
+
+	go pool.submit(source)
+	return nil
+}
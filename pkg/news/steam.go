@@ -1,11 +1,12 @@
 package news
 
 import (
+	"context"
 	"encoding/xml"
 	"fmt"
 	"go-nelson/pkg/structures"
 	"go-nelson/pkg/utils"
-	"log"
+	"log/slog"
 	"strings"
 )
 
@@ -32,12 +33,21 @@ type SteamItem struct {
 	} `xml:"enclosure"`
 }
 
-func ParseSteam() ([]structures.News, error) {
-	log.Println("Парсинг новостей с Steam Developer")
+func init() {
+	RegisterSource(&parserSource{
+		name:     "steam_developers",
+		provider: "Steam Developer",
+		tag:      "Steam",
+		parse:    ParseSteam,
+	})
+}
+
+func ParseSteam(ctx context.Context) ([]structures.News, error) {
+	slog.Debug("Parsing source", "provider", "Steam Developer")
 	var news []structures.News
-	fetcher := utils.NewFetcher()
+	fetcher := utils.NewConditionalFetcher()
 
-	data, err := fetcher.Fetch("https://store.steampowered.com/feeds/news/group/4145017")
+	data, err := fetcher.FetchContext(ctx, "https://store.steampowered.com/feeds/news/group/4145017")
 	if err != nil {
 		return nil, fmt.Errorf("ошибка при получении RSS ленты Steam Developer: %w", err)
 	}
@@ -59,7 +69,7 @@ func ParseSteam() ([]structures.News, error) {
 
 		_, err := utils.ParseRSSDate(item.PubDate)
 		if err != nil {
-			log.Printf("Ошибка при парсинге даты публикации Steam Developer: %v", err)
+			slog.Warn("Failed to parse publication date", "provider", "Steam Developer", "error", err)
 		}
 
 		imageURL := item.Enclosure.URL
@@ -1,11 +1,12 @@
 package news
 
 import (
+	"context"
 	"encoding/xml"
 	"fmt"
 	"go-nelson/pkg/structures"
 	"go-nelson/pkg/utils"
-	"log"
+	"log/slog"
 	"strings"
 )
 
@@ -46,14 +47,23 @@ type StopGameEnclosure struct {
 	Type   string `xml:"type,attr"`
 }
 
-func ParseStopGame() ([]structures.News, error) {
-	log.Println("Парсинг новостей с StopGame")
+func init() {
+	RegisterSource(&parserSource{
+		name:     "stopgame",
+		provider: "StopGame",
+		tag:      "StopGame",
+		parse:    ParseStopGame,
+	})
+}
+
+func ParseStopGame(ctx context.Context) ([]structures.News, error) {
+	slog.Debug("Parsing source", "provider", "StopGame")
 	var news []structures.News
-	fetcher := utils.NewFetcher()
+	fetcher := utils.NewConditionalFetcher()
 
-	data, err := fetcher.Fetch("https://rss.stopgame.ru/rss_all.xml")
+	data, err := fetcher.FetchContext(ctx, "https://rss.stopgame.ru/rss_all.xml")
 	if err != nil {
-		return nil, fmt.Errorf("ошибка при получении RSS-ленты StopGame: %v", err)
+		return nil, fmt.Errorf("ошибка при получении RSS-ленты StopGame: %w", err)
 	}
 
 	var rss StopGameRSS
@@ -74,7 +84,7 @@ func ParseStopGame() ([]structures.News, error) {
 
 		_, err := utils.ParseRSSDate(item.PubDate)
 		if err != nil {
-			log.Printf("Ошибка при парсинге даты публикации новости StopGame: %v", err)
+			slog.Warn("Failed to parse publication date", "provider", "StopGame", "error", err)
 		}
 
 		imageURL := item.Enclosure.URL
@@ -2,92 +2,231 @@ package services
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"go-nelson/pkg"
-	"log"
-	"net/http"
-	"path/filepath"
+	"go-nelson/pkg/db"
+	"log/slog"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
-	"image/jpeg"
-
+	"go-nelson/pkg/metrics"
 	"go-nelson/pkg/structures"
-	"go-nelson/pkg/utils"
 
 	"github.com/bwmarrin/discordgo"
-	"golang.org/x/image/webp"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 var discordSession *discordgo.Session
-var discordNewsChannel = make(chan structures.News, 500)
-var forumTagsCache map[string]string
-
-var requiredTags = []string{
-	"EGS",
-	"DTF",
-	"Ixbt",
-	"Steam",
-	"Disgusting",
-	"3Dnews",
-	"GameDev",
-	"StopGame",
+
+var (
+	forumTagsMu    sync.RWMutex
+	forumTagsCache = make(map[string]map[string]string)
+)
+
+const maxDiscordImageSize = 25 * 1024 * 1024
+
+var (
+	providerTagsMu  sync.RWMutex
+	providerTags    = make(map[string]string)
+	sourceProviders = make(map[string]string)
+)
+
+func RegisterProvider(sourceName, provider, tagName string) {
+	providerTagsMu.Lock()
+	defer providerTagsMu.Unlock()
+
+	sourceProviders[strings.ToLower(sourceName)] = provider
+	if tagName != "" {
+		providerTags[provider] = tagName
+	}
+}
+
+type RegisteredSource struct {
+	Name     string
+	Provider string
+}
+
+func RegisteredSources() []RegisteredSource {
+	providerTagsMu.RLock()
+	defer providerTagsMu.RUnlock()
+
+	result := make([]RegisteredSource, 0, len(sourceProviders))
+	for name, provider := range sourceProviders {
+		result = append(result, RegisteredSource{Name: name, Provider: provider})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
+func registeredProviders() []string {
+	seen := make(map[string]bool)
+	var providers []string
+	for _, source := range RegisteredSources() {
+		if !seen[source.Provider] {
+			seen[source.Provider] = true
+			providers = append(providers, source.Provider)
+		}
+	}
+
+	sort.Strings(providers)
+	return providers
+}
+
+// FindProvider ищет провайдера по названию или имени парсера без учета регистра
+func FindProvider(name string) (string, bool) {
+	name = strings.TrimSpace(name)
+	for _, source := range RegisteredSources() {
+		if strings.EqualFold(source.Name, name) || strings.EqualFold(source.Provider, name) {
+			return source.Provider, true
+		}
+	}
+	return "", false
+}
+
+// Источник в настройках можно указать как по имени парсера, так и по названию провайдера
+func resolveProviders(sources []string) []string {
+	providerTagsMu.RLock()
+	defer providerTagsMu.RUnlock()
+
+	providers := make([]string, 0, len(sources))
+	for _, source := range sources {
+		if provider, ok := sourceProviders[strings.ToLower(strings.TrimSpace(source))]; ok {
+			providers = append(providers, strings.ToLower(provider))
+		} else {
+			providers = append(providers, strings.ToLower(strings.TrimSpace(source)))
+		}
+	}
+
+	return providers
+}
+
+func requiredTags(target deliveryTarget) []string {
+	providerTagsMu.RLock()
+	defer providerTagsMu.RUnlock()
+
+	seen := make(map[string]bool)
+	var tags []string
+	for provider := range providerTags {
+		if len(target.Providers) > 0 && !containsFold(target.Providers, provider) {
+			continue
+		}
+
+		tagName := tagNameForProvider(target, provider)
+		if !seen[strings.ToLower(tagName)] {
+			seen[strings.ToLower(tagName)] = true
+			tags = append(tags, tagName)
+		}
+	}
+
+	sort.Strings(tags)
+	return tags
+}
+
+// Вызывается под providerTagsMu
+func tagNameForProvider(target deliveryTarget, provider string) string {
+	if tagName, ok := target.Tags[provider]; ok && tagName != "" {
+		return tagName
+	}
+	return providerTags[provider]
 }
 
-func StartDiscord() {
-	log.Println("Запуск Discord сервиса")
+func StartDiscord(ctx context.Context) {
+	slog.Info("Starting Discord service")
 	var err error
 
 	discordSession, err = discordgo.New("Bot " + pkg.Discord.Token)
 	if err != nil {
-		log.Printf("Ошибка при создании Discord сессии: %v", err)
+		slog.Error("Failed to create Discord session", "error", err)
 		return
 	}
 
+	discordSession.AddHandler(func(_ *discordgo.Session, _ *discordgo.RateLimit) {
+		metrics.RateLimitHits.WithLabelValues(DestinationDiscord).Inc()
+	})
+
 	err = discordSession.Open()
 	if err != nil {
-		log.Printf("Ошибка при подключении к Discord: %v", err)
+		slog.Error("Failed to connect to Discord", "error", err)
 		return
 	}
 
-	initForumTags()
+	handler := destinationHandler{
+		publish: sendToDiscordWithRateLimiting,
+		edit:    editDiscordNews,
+		delete:  deleteDiscordNews,
+		mark:    markDiscordNews,
+	}
+
+	for _, target := range enabledTargets(DestinationDiscord) {
+		initForumTags(target)
+		startOutboxWorker(ctx, target, handler)
+	}
 
-	go handleDiscordQueue()
-	log.Println("Discord сервис успешно запущен")
+	registerDiscordCommands()
+	slog.Info("Discord service started")
 }
 
-func initForumTags() {
-	log.Println("Инициализация тегов форума Discord")
-	forumTagsCache = make(map[string]string)
+func discordChannel(channelID string) (*discordgo.Channel, error) {
+	if channel, err := discordSession.State.Channel(channelID); err == nil {
+		return channel, nil
+	}
+
+	return discordSession.Channel(channelID)
+}
 
-	forumChannel, err := discordSession.Channel(pkg.Discord.NewsForumId)
+func initForumTags(target deliveryTarget) {
+	forumID := target.ChannelID
+
+	forumChannel, err := discordChannel(forumID)
 	if err != nil {
-		log.Printf("Ошибка при получении информации о форуме: %v", err)
+		slog.Error("Failed to get Discord channel info", "destination", target.Name, "channel_id", forumID, "error", err)
 		return
 	}
 
+	if target.GuildID != "" && forumChannel.GuildID != target.GuildID {
+		slog.Warn("Discord channel belongs to another guild", "destination", target.Name, "channel_id", forumID, "guild_id", forumChannel.GuildID, "expected_guild_id", target.GuildID)
+	}
+
 	if forumChannel.Type != discordgo.ChannelTypeGuildForum {
-		log.Printf("Канал %s не является форумом", pkg.Discord.NewsForumId)
+		slog.Info("Discord channel is not a forum, news will be sent as messages", "destination", target.Name, "channel_id", forumID)
 		return
 	}
 
+	slog.Info("Initializing Discord forum tags", "destination", target.Name, "channel_id", forumID)
+
+	tags := make(map[string]string)
 	for _, tag := range forumChannel.AvailableTags {
-		forumTagsCache[strings.ToLower(tag.Name)] = tag.ID
+		tags[strings.ToLower(tag.Name)] = tag.ID
+	}
+
+	forumTagsMu.Lock()
+	if cached, ok := forumTagsCache[forumID]; ok {
+		for name, id := range cached {
+			tags[name] = id
+		}
 	}
+	forumTagsCache[forumID] = tags
+	forumTagsMu.Unlock()
 
-	for _, tagName := range requiredTags {
-		if _, exists := forumTagsCache[strings.ToLower(tagName)]; !exists {
-			createForumTag(tagName)
+	for _, tagName := range requiredTags(target) {
+		if _, exists := tags[strings.ToLower(tagName)]; !exists {
+			createForumTag(forumID, tagName)
 		}
 	}
 }
 
-func createForumTag(tagName string) {
-	log.Printf("Создание нового тега '%s' для форума Discord", tagName)
+func createForumTag(forumID, tagName string) {
+	slog.Info("Creating Discord forum tag", "tag", tagName, "channel_id", forumID)
 
-	forumChannel, err := discordSession.Channel(pkg.Discord.NewsForumId)
+	forumChannel, err := discordSession.Channel(forumID)
 	if err != nil {
-		log.Printf("Ошибка при получении информации о форуме для создания тега: %v", err)
+		slog.Error("Failed to get forum info to create tag", "channel_id", forumID, "error", err)
 		return
 	}
 
@@ -101,96 +240,61 @@ func createForumTag(tagName string) {
 		AvailableTags: &updatedTags,
 	}
 
-	updatedForum, err := discordSession.ChannelEdit(pkg.Discord.NewsForumId, channelEdit)
+	updatedForum, err := discordSession.ChannelEdit(forumID, channelEdit)
 	if err != nil {
-		log.Printf("Ошибка при создании тега '%s': %v", tagName, err)
+		slog.Error("Failed to create Discord forum tag", "tag", tagName, "channel_id", forumID, "error", err)
 		return
 	}
 
+	forumTagsMu.Lock()
+	defer forumTagsMu.Unlock()
+
 	for _, tag := range updatedForum.AvailableTags {
 		if strings.EqualFold(tag.Name, tagName) {
-			forumTagsCache[strings.ToLower(tagName)] = tag.ID
+			forumTagsCache[forumID][strings.ToLower(tagName)] = tag.ID
 			break
 		}
 	}
 }
 
 func CloseDiscord() {
-	log.Println("Закрытие соединения с Discord")
+	slog.Info("Closing Discord connection")
 	if discordSession != nil {
 		discordSession.Close()
 	}
 }
 
-func handleDiscordQueue() {
-	log.Println("Запуск обработчика очереди сообщений Discord")
-
-	ticker := time.NewTicker(5 * time.Second)
-	defer ticker.Stop()
-
-	for range ticker.C {
-		select {
-		case news := <-discordNewsChannel:
-			err := sendToDiscordWithRateLimiting(news)
-			if err != nil {
-				log.Printf("Ошибка при отправке новости в Discord: %v", err)
-				if strings.Contains(err.Error(), "rate limit") {
-					time.Sleep(10 * time.Second)
-					discordNewsChannel <- news
-				}
-			}
+func getTagForProvider(target deliveryTarget, provider string) string {
+	providerTagsMu.RLock()
+	tagName := tagNameForProvider(target, provider)
+	providerTagsMu.RUnlock()
 
-			time.Sleep(1 * time.Second)
-		default:
-		}
+	if tagName == "" {
+		return ""
 	}
-}
 
-func SendNewsToThread(news structures.News) error {
+	forumTagsMu.RLock()
+	defer forumTagsMu.RUnlock()
 
-	select {
-	case discordNewsChannel <- news:
-		return nil
-	default:
-		log.Printf("ОШИБКА: Канал Discord переполнен, новость отброшена: %s", news.Title)
-		return fmt.Errorf("канал discord переполнен")
-	}
+	return forumTagsCache[target.ChannelID][strings.ToLower(tagName)]
 }
 
-func getTagForProvider(provider string) string {
-	var tagName string
-
-	switch provider {
-	case "DTF":
-		tagName = "DTF"
-	case "IxbtGames":
-		tagName = "Ixbt"
-	case "Steam":
-		tagName = "Steam"
-	case "Disgustingmen":
-		tagName = "Disgusting"
-	case "3DNews":
-		tagName = "3Dnews"
-	case "Epic Games Store":
-		tagName = "EGS"
-	case "GameDev.ru":
-		tagName = "GameDev"
-	case "StopGame":
-		tagName = "StopGame"
-	default:
-		return ""
+func sendToDiscordWithRateLimiting(ctx context.Context, target deliveryTarget, news *structures.News, delivery *structures.Delivery, _ string) (string, error) {
+	if discordSession == nil {
+		return "", fmt.Errorf("discord бот не настроен")
 	}
 
-	return forumTagsCache[strings.ToLower(tagName)]
-}
+	if target.ChannelID == "" {
+		return "", fmt.Errorf("не указан ID канала форума для новостей")
+	}
 
-func sendToDiscordWithRateLimiting(news structures.News) error {
-	if discordSession == nil {
-		return fmt.Errorf("discord бот не настроен")
+	channel, err := discordChannel(target.ChannelID)
+	if err != nil {
+		return "", fmt.Errorf("ошибка при получении канала %s: %w", target.ChannelID, err)
 	}
 
-	if pkg.Discord.NewsForumId == "" {
-		return fmt.Errorf("не указан ID канала форума для новостей")
+	if channel.Type != discordgo.ChannelTypeGuildForum {
+		return sendDiscordChannelMessage(target, news)
 	}
 
 	title := formatTitle(news.Title)
@@ -200,7 +304,7 @@ func sendToDiscordWithRateLimiting(news structures.News) error {
 		AutoArchiveDuration: 10080,
 	}
 
-	tagID := getTagForProvider(news.Provider)
+	tagID := getTagForProvider(target, news.Provider)
 	if tagID != "" {
 		threadParams.AppliedTags = []string{tagID}
 	}
@@ -216,123 +320,298 @@ func sendToDiscordWithRateLimiting(news structures.News) error {
 		processAndAttachImage(news.Images[0], messageSend)
 	}
 
-	thread, err := discordSession.ForumThreadStartComplex(pkg.Discord.NewsForumId, threadParams, messageSend)
+	thread, err := discordSession.ForumThreadStartComplex(target.ChannelID, threadParams, messageSend)
 	if err != nil {
-		log.Printf("Ошибка создания треда для новости '%s': %v", news.Title, err)
-		return err
+		slog.Error("Failed to create Discord thread", "destination", target.Name, "news_id", news.Id.Hex(), "error", err)
+		return "", err
 	}
 
-	// Отправка дополнительных частей длинного описания, если оно больше 1800 символов
+	delivery.PartMessageIDs = nil
+	syncDescriptionParts(ctx, target, thread.ID, news, delivery)
+
+	// ID стартового сообщения в треде форума совпадает с ID самого треда
+	return thread.ID, nil
+}
+
+// Продолжение описания длиннее 1800 символов отправляется в тред отдельными сообщениями. Их ID
+// хранятся в доставке, чтобы при правке обновить текст на месте и не нарушить порядок сообщений
+func syncDescriptionParts(ctx context.Context, target deliveryTarget, threadID string, news *structures.News, delivery *structures.Delivery) {
+	var parts []string
 	if len(news.Description) > 1800 {
 		// Разделяем оставшуюся часть на фрагменты по 2000 символов
-		remainingParts := splitLongText(news.Description[min(1800, len(news.Description)):], 2000)
-
-		for _, part := range remainingParts {
-			if part == "" {
-				continue
+		for _, part := range splitLongText(news.Description[1800:], 2000) {
+			if part != "" {
+				parts = append(parts, part)
 			}
+		}
+	}
+
+	previous := delivery.PartMessageIDs
+	ids := make([]string, 0, len(parts))
 
-			_, err := discordSession.ChannelMessageSend(thread.ID, part)
-			if err != nil {
-				log.Printf("Ошибка отправки дополнительной части описания в тред '%s': %v", news.Title, err)
+	for i, part := range parts {
+		if i < len(previous) {
+			if _, err := discordSession.ChannelMessageEdit(threadID, previous[i], part); err != nil {
+				slog.Error("Failed to edit description continuation", "destination", target.Name, "news_id", news.Id.Hex(), "error", err)
 			}
+			ids = append(ids, previous[i])
+			continue
+		}
+
+		message, err := discordSession.ChannelMessageSend(threadID, part)
+		if err != nil {
+			slog.Error("Failed to send description continuation", "destination", target.Name, "news_id", news.Id.Hex(), "error", err)
+			continue
+		}
+		ids = append(ids, message.ID)
+
+		// Небольшая задержка для предотвращения ошибок рейт-лимита
+		select {
+		case <-ctx.Done():
+			delivery.PartMessageIDs = ids
+			return
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
 
-			// Небольшая задержка для предотвращения ошибок рейт-лимита
-			time.Sleep(500 * time.Millisecond)
+	// Описание стало короче: лишние продолжения удаляются
+	for _, id := range previous[min(len(parts), len(previous)):] {
+		if err := discordSession.ChannelMessageDelete(threadID, id); err != nil {
+			slog.Error("Failed to delete description continuation", "destination", target.Name, "news_id", news.Id.Hex(), "error", err)
 		}
 	}
 
-	return nil
+	delivery.PartMessageIDs = ids
 }
 
-func downloadImage(url string) ([]byte, string, string, error) {
-	fetcher := utils.NewFetcher()
+// В обычный текстовый канал новость отправляется одним сообщением без продолжения описания
+func sendDiscordChannelMessage(target deliveryTarget, news *structures.News) (string, error) {
+	messageSend := &discordgo.MessageSend{
+		Content: makeChannelMessage(news, news.Title),
+	}
 
-	imageData, err := fetcher.Fetch(url)
+	if len(news.Images) > 0 {
+		processAndAttachImage(news.Images[0], messageSend)
+	}
+
+	message, err := discordSession.ChannelMessageSendComplex(target.ChannelID, messageSend)
 	if err != nil {
-		return nil, "", "", err
+		return "", fmt.Errorf("ошибка отправки сообщения в канал %s: %w", target.ChannelID, err)
 	}
 
-	contentType := http.DetectContentType(imageData)
+	return message.ID, nil
+}
 
-	fileName := filepath.Base(url)
-	if fileName == "" || fileName == "." || strings.Contains(fileName, "?") {
-		ext := getExtensionFromContentType(contentType)
-		fileName = fmt.Sprintf("image%s", ext)
+func makeChannelMessage(news *structures.News, title string) string {
+	description := makeDescription(news.URL, news.Description[:min(1500, len(news.Description))], news.Tags, "", news.Provider, len(news.Images) > 0)
+	return fmt.Sprintf("## %s\n\n%s", title, description)
+}
+
+func processAndAttachImage(imageURL string, messageData *discordgo.MessageSend) {
+	imageData, fileName, err := prepareImage(imageURL, maxDiscordImageSize)
+	if err != nil {
+		slog.Warn("Failed to prepare image", "error", err)
+		return
 	}
 
-	return imageData, contentType, fileName, nil
+	messageData.Files = []*discordgo.File{
+		{
+			Name:   fileName,
+			Reader: bytes.NewReader(imageData),
+		},
+	}
 }
 
-func convertWebpToJpg(webpData []byte) ([]byte, string, string, error) {
-	webpImg, err := webp.Decode(bytes.NewReader(webpData))
+func isDiscordForum(channelID string) (bool, error) {
+	channel, err := discordChannel(channelID)
 	if err != nil {
-		return nil, "", "", err
+		return false, fmt.Errorf("ошибка при получении канала %s: %w", channelID, err)
 	}
 
-	jpgBuf := new(bytes.Buffer)
+	return channel.Type == discordgo.ChannelTypeGuildForum, nil
+}
 
-	err = jpeg.Encode(jpgBuf, webpImg, &jpeg.Options{Quality: 85})
+// DiscordThreadURLs возвращает ссылки на опубликованные в Discord новости. Гильдия берется из назначения,
+// которому принадлежит тред или сообщение, а при нескольких публикациях выбирается первое назначение из настроек
+func DiscordThreadURLs(news []*structures.News) map[primitive.ObjectID]string {
+	urls := make(map[primitive.ObjectID]string)
+
+	targets := make(map[string]deliveryTarget)
+	var names []string
+	for _, target := range deliveryTargets() {
+		if target.Type == DestinationDiscord && target.GuildID != "" {
+			targets[target.Name] = target
+			names = append(names, target.Name)
+		}
+	}
+	if len(names) == 0 {
+		return urls
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(news))
+	for _, n := range news {
+		ids = append(ids, n.Id)
+	}
+
+	deliveries, err := db.NewDeliveryRepository().FindWithMessages(ids, names)
 	if err != nil {
-		return nil, "", "", err
+		slog.Error("Failed to load Discord messages", "count", len(ids), "error", err)
 	}
 
-	jpgData := jpgBuf.Bytes()
-	fileName := "image.jpg"
+	messages := make(map[primitive.ObjectID]map[string]string)
+	for _, delivery := range deliveries {
+		if messages[delivery.NewsID] == nil {
+			messages[delivery.NewsID] = make(map[string]string)
+		}
+		messages[delivery.NewsID][delivery.Destination] = delivery.MessageID
+	}
+
+	for _, n := range news {
+		for _, name := range names {
+			messageID := messages[n.Id][name]
+			if messageID == "" {
+				messageID = legacyMessageID(targets[name], n)
+			}
+			if messageID != "" {
+				urls[n.Id] = discordMessageURL(targets[name], messageID)
+				break
+			}
+		}
+	}
 
-	return jpgData, "image/jpeg", fileName, nil
+	return urls
 }
 
-func processAndAttachImage(imageURL string, messageData *discordgo.MessageSend) {
-	imageData, contentType, fileName, err := downloadImage(imageURL)
-	if err != nil {
-		log.Printf("Ошибка при скачивании изображения: %v", err)
-		return
+// В форуме ID треда совпадает с ID стартового сообщения, а в обычном канале ссылка ведет на само сообщение
+func discordMessageURL(target deliveryTarget, messageID string) string {
+	forumTagsMu.RLock()
+	_, forum := forumTagsCache[target.ChannelID]
+	forumTagsMu.RUnlock()
+
+	if forum {
+		return fmt.Sprintf("https://discord.com/channels/%s/%s", target.GuildID, messageID)
 	}
+	return fmt.Sprintf("https://discord.com/channels/%s/%s/%s", target.GuildID, target.ChannelID, messageID)
+}
 
-	if len(imageData) > 25*1024*1024 {
-		return
+func editDiscordNews(ctx context.Context, target deliveryTarget, news *structures.News, delivery *structures.Delivery, messageID string) (string, error) {
+	if discordSession == nil {
+		return "", fmt.Errorf("discord бот не настроен")
 	}
 
-	if strings.Contains(contentType, "webp") {
-		convertedData, newContentType, newFileName, err := convertWebpToJpg(imageData)
+	if messageID == "" {
+		slog.Info("News not published, skipping edit", "destination", target.Name, "news_id", news.Id.Hex(), "title", news.Title)
+		return "", nil
+	}
+
+	forum, err := isDiscordForum(target.ChannelID)
+	if err != nil {
+		return messageID, err
+	}
+
+	var content string
+	var messageEdit *discordgo.MessageEdit
+	if forum {
+		archived := false
+		_, err := discordSession.ChannelEdit(messageID, &discordgo.ChannelEdit{
+			Name:     formatTitle(news.Title),
+			Archived: &archived,
+		})
 		if err != nil {
-			log.Printf("Ошибка при конвертации webp в jpg: %v", err)
-		} else {
-			imageData = convertedData
-			contentType = newContentType
-			fileName = newFileName
+			return messageID, fmt.Errorf("ошибка при переименовании треда: %w", err)
 		}
-	}
 
-	if strings.Contains(fileName, "?") {
-		ext := getExtensionFromContentType(contentType)
-		fileName = fmt.Sprintf("image%s", ext)
+		content = makeDescription(news.URL, news.Description[:min(1800, len(news.Description))], news.Tags, news.Title, news.Provider, len(news.Images) > 0)
+		messageEdit = discordgo.NewMessageEdit(messageID, messageID)
+	} else {
+		content = makeChannelMessage(news, news.Title)
+		messageEdit = discordgo.NewMessageEdit(target.ChannelID, messageID)
 	}
+	messageEdit.Content = &content
 
-	if len(imageData) < 25*1024*1024 {
-		messageData.Files = []*discordgo.File{
-			{
-				Name:   fileName,
-				Reader: bytes.NewReader(imageData),
-			},
+	if len(news.Images) > 0 {
+		messageSend := &discordgo.MessageSend{}
+		processAndAttachImage(news.Images[0], messageSend)
+		if len(messageSend.Files) > 0 {
+			attachments := []*discordgo.MessageAttachment{}
+			messageEdit.Files = messageSend.Files
+			messageEdit.Attachments = &attachments
 		}
 	}
+
+	_, err = discordSession.ChannelMessageEditComplex(messageEdit)
+	if err != nil {
+		return messageID, fmt.Errorf("ошибка при редактировании сообщения: %w", err)
+	}
+
+	if forum {
+		syncDescriptionParts(ctx, target, messageID, news, delivery)
+	}
+
+	slog.Info("News updated", "destination", target.Name, "news_id", news.Id.Hex(), "title", news.Title)
+	return messageID, nil
 }
 
-func getExtensionFromContentType(contentType string) string {
-	switch {
-	case strings.Contains(contentType, "jpeg") || strings.Contains(contentType, "jpg"):
-		return ".jpg"
-	case strings.Contains(contentType, "png"):
-		return ".png"
-	case strings.Contains(contentType, "gif"):
-		return ".gif"
-	case strings.Contains(contentType, "webp"):
-		return ".webp"
-	default:
-		return ".jpg"
+func deleteDiscordNews(_ context.Context, target deliveryTarget, news *structures.News, _ *structures.Delivery, messageID string) (string, error) {
+	if discordSession == nil {
+		return "", fmt.Errorf("discord бот не настроен")
+	}
+
+	if messageID == "" {
+		return "", nil
 	}
+
+	forum, err := isDiscordForum(target.ChannelID)
+	if err != nil {
+		return messageID, err
+	}
+
+	if forum {
+		_, err = discordSession.ChannelDelete(messageID)
+	} else {
+		err = discordSession.ChannelMessageDelete(target.ChannelID, messageID)
+	}
+	if err != nil {
+		return messageID, fmt.Errorf("ошибка при удалении публикации: %w", err)
+	}
+
+	slog.Info("News deleted", "destination", target.Name, "news_id", news.Id.Hex(), "title", news.Title)
+	return messageID, nil
+}
+
+func markDiscordNews(_ context.Context, target deliveryTarget, news *structures.News, _ *structures.Delivery, messageID string) (string, error) {
+	if discordSession == nil {
+		return "", fmt.Errorf("discord бот не настроен")
+	}
+
+	if messageID == "" {
+		return "", nil
+	}
+
+	forum, err := isDiscordForum(target.ChannelID)
+	if err != nil {
+		return messageID, err
+	}
+
+	title := retractionMarker() + " " + news.Title
+	if forum {
+		locked := true
+		archived := true
+		_, err = discordSession.ChannelEdit(messageID, &discordgo.ChannelEdit{
+			Name:     formatTitle(title),
+			Locked:   &locked,
+			Archived: &archived,
+		})
+	} else {
+		content := makeChannelMessage(news, title)
+		_, err = discordSession.ChannelMessageEdit(target.ChannelID, messageID, content)
+	}
+	if err != nil {
+		return messageID, fmt.Errorf("ошибка при пометке публикации: %w", err)
+	}
+
+	slog.Info("News marked as retracted", "destination", target.Name, "news_id", news.Id.Hex(), "title", news.Title)
+	return messageID, nil
 }
 
 func formatTitle(title string) string {
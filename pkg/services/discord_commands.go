@@ -0,0 +1,480 @@
+package services
+
+import (
+	"fmt"
+	"go-nelson/pkg/db"
+	"go-nelson/pkg/structures"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	newsPageDefaultSize = 5
+	newsPageMaxSize     = 10
+	newsEmbedMaxDesc    = 300
+	// Discord отклоняет сообщение, если суммарный текст всех embed превышает 6000 символов
+	discordEmbedsMaxText = 6000
+	newsSearchMaxLength  = 60
+
+	newsListLatest = "latest"
+	newsListSearch = "search"
+	newsListFree   = "free"
+)
+
+type discordCommandHandler func(s *discordgo.Session, i *discordgo.InteractionCreate)
+
+var (
+	discordCommands          []*discordgo.ApplicationCommand
+	discordCommandHandlers   = make(map[string]discordCommandHandler)
+	discordComponentHandlers = make(map[string]discordCommandHandler)
+)
+
+func init() {
+	registerDiscordCommand(newsCommand(), handleNewsCommand)
+	registerDiscordComponent("news", handleNewsPage)
+}
+
+func registerDiscordCommand(command *discordgo.ApplicationCommand, handler discordCommandHandler) {
+	discordCommands = append(discordCommands, command)
+	discordCommandHandlers[command.Name] = handler
+}
+
+// Обработчик кнопки выбирается по префиксу CustomID до первого двоеточия
+func registerDiscordComponent(prefix string, handler discordCommandHandler) {
+	discordComponentHandlers[prefix] = handler
+}
+
+func newsCommand() *discordgo.ApplicationCommand {
+	minCount := 1.0
+
+	return &discordgo.ApplicationCommand{
+		Name:        "news",
+		Description: "Архив новостей",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "latest",
+				Description: "Последние новости",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:         discordgo.ApplicationCommandOptionString,
+						Name:         "provider",
+						Description:  "Источник новостей",
+						Autocomplete: true,
+					},
+					{
+						Type:        discordgo.ApplicationCommandOptionInteger,
+						Name:        "count",
+						Description: "Количество новостей на странице",
+						MinValue:    &minCount,
+						MaxValue:    newsPageMaxSize,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "search",
+				Description: "Поиск по заголовкам и описаниям",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionString,
+						Name:        "query",
+						Description: "Текст для поиска",
+						Required:    true,
+						MaxLength:   newsSearchMaxLength,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "sources",
+				Description: "Список источников новостей",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "free-games",
+				Description: "Текущие бесплатные раздачи",
+			},
+		},
+	}
+}
+
+func registerDiscordCommands() {
+	discordSession.AddHandler(handleDiscordInteraction)
+
+	appID := discordSession.State.User.ID
+
+	guilds := make(map[string]bool)
+	for _, target := range enabledTargets(DestinationDiscord) {
+		if target.GuildID != "" {
+			guilds[target.GuildID] = true
+		}
+	}
+
+	// Без указанных серверов команды регистрируются глобально
+	if len(guilds) == 0 {
+		guilds[""] = true
+	}
+
+	for guildID := range guilds {
+		if _, err := discordSession.ApplicationCommandBulkOverwrite(appID, guildID, discordCommands); err != nil {
+			slog.Error("Failed to register Discord commands", "guild_id", guildID, "error", err)
+			continue
+		}
+		slog.Info("Discord commands registered", "guild_id", guildID)
+	}
+}
+
+func handleDiscordInteraction(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	switch i.Type {
+	case discordgo.InteractionApplicationCommand, discordgo.InteractionApplicationCommandAutocomplete:
+		if handler, ok := discordCommandHandlers[i.ApplicationCommandData().Name]; ok {
+			handler(s, i)
+		}
+	case discordgo.InteractionMessageComponent:
+		prefix, _, _ := strings.Cut(i.MessageComponentData().CustomID, ":")
+		if handler, ok := discordComponentHandlers[prefix]; ok {
+			handler(s, i)
+		}
+	}
+}
+
+func handleNewsCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := i.ApplicationCommandData()
+	if len(data.Options) == 0 {
+		return
+	}
+
+	subcommand := data.Options[0]
+	options := make(map[string]*discordgo.ApplicationCommandInteractionDataOption)
+	for _, option := range subcommand.Options {
+		options[option.Name] = option
+	}
+
+	if i.Type == discordgo.InteractionApplicationCommandAutocomplete {
+		respondProviderAutocomplete(s, i, options["provider"])
+		return
+	}
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		slog.Error("Failed to respond to Discord command", "error", err)
+		return
+	}
+
+	var edit *discordgo.WebhookEdit
+	switch subcommand.Name {
+	case "latest":
+		provider := ""
+		if option, ok := options["provider"]; ok {
+			provider = option.StringValue()
+		}
+		size := newsPageDefaultSize
+		if option, ok := options["count"]; ok {
+			size = int(option.IntValue())
+		}
+		edit, err = renderNewsPage(newsListLatest, provider, 0, size)
+	case "search":
+		edit, err = renderNewsPage(newsListSearch, options["query"].StringValue(), 0, newsPageDefaultSize)
+	case "free-games":
+		edit, err = renderNewsPage(newsListFree, "", 0, newsPageDefaultSize)
+	case "sources":
+		edit, err = renderSources()
+	default:
+		return
+	}
+
+	if err != nil {
+		slog.Error("Discord command failed", "command", "news", "subcommand", subcommand.Name, "error", err)
+		edit = discordErrorEdit()
+	}
+
+	if _, err := s.InteractionResponseEdit(i.Interaction, edit); err != nil {
+		slog.Error("Failed to send Discord command result", "command", "news", "subcommand", subcommand.Name, "error", err)
+	}
+}
+
+func respondProviderAutocomplete(s *discordgo.Session, i *discordgo.InteractionCreate, option *discordgo.ApplicationCommandInteractionDataOption) {
+	typed := ""
+	if option != nil {
+		typed = strings.ToLower(option.StringValue())
+	}
+
+	var choices []*discordgo.ApplicationCommandOptionChoice
+	for _, provider := range registeredProviders() {
+		if len(choices) == 25 {
+			break
+		}
+		if strings.Contains(strings.ToLower(provider), typed) {
+			choices = append(choices, &discordgo.ApplicationCommandOptionChoice{Name: provider, Value: provider})
+		}
+	}
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionApplicationCommandAutocompleteResult,
+		Data: &discordgo.InteractionResponseData{
+			Choices: choices,
+		},
+	})
+	if err != nil {
+		slog.Error("Failed to respond to Discord autocomplete", "error", err)
+	}
+}
+
+// CustomID кнопок: news:<список>:<страница>:<размер>:<аргумент>
+func handleNewsPage(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	parts := strings.SplitN(i.MessageComponentData().CustomID, ":", 5)
+	if len(parts) != 5 {
+		return
+	}
+
+	page, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return
+	}
+	size, err := strconv.Atoi(parts[3])
+	if err != nil {
+		return
+	}
+
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredMessageUpdate,
+	})
+	if err != nil {
+		slog.Error("Failed to respond to Discord button", "error", err)
+		return
+	}
+
+	edit, err := renderNewsPage(parts[1], parts[4], page, size)
+	if err != nil {
+		slog.Error("Failed to load news page", "error", err)
+		edit = discordErrorEdit()
+	}
+
+	if _, err := s.InteractionResponseEdit(i.Interaction, edit); err != nil {
+		slog.Error("Failed to update news page", "error", err)
+	}
+}
+
+func renderNewsPage(list, argument string, page, size int) (*discordgo.WebhookEdit, error) {
+	if size <= 0 || size > newsPageMaxSize {
+		size = newsPageDefaultSize
+	}
+
+	var filter db.NewsFilter
+	var title string
+	switch list {
+	case newsListLatest:
+		filter.Provider = argument
+		title = "Последние новости"
+		if argument != "" {
+			title += " " + argument
+		}
+	case newsListSearch:
+		filter.Query = argument
+		title = fmt.Sprintf("Поиск: %s", argument)
+	case newsListFree:
+		filter.ActiveOffers = true
+		title = "Бесплатные раздачи"
+	default:
+		return nil, fmt.Errorf("неизвестный список новостей %s", list)
+	}
+
+	newsRepo := db.NewNewsRepository()
+
+	total, err := newsRepo.Count(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	pages := int((total + int64(size) - 1) / int64(size))
+	if page >= pages {
+		page = pages - 1
+	}
+	if page < 0 {
+		page = 0
+	}
+
+	news, err := newsRepo.Find(filter, int64(page*size), int64(size))
+	if err != nil {
+		return nil, err
+	}
+
+	content := fmt.Sprintf("**%s** · страница %d из %d · всего %d", title, page+1, max(pages, 1), total)
+	if total == 0 {
+		content = fmt.Sprintf("**%s** · ничего не найдено", title)
+	}
+
+	threadURLs := DiscordThreadURLs(news)
+	embeds := make([]*discordgo.MessageEmbed, 0, len(news))
+	for _, n := range news {
+		embeds = append(embeds, newsEmbed(n, threadURLs[n.Id]))
+	}
+	fitEmbedDescriptions(embeds, news)
+
+	components := []discordgo.MessageComponent{}
+	if pages > 1 {
+		customID := func(page int) string {
+			return fmt.Sprintf("news:%s:%d:%d:%s", list, page, size, argument)
+		}
+
+		components = append(components, discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.Button{
+					Label:    "◀",
+					Style:    discordgo.SecondaryButton,
+					CustomID: customID(page - 1),
+					Disabled: page == 0,
+				},
+				discordgo.Button{
+					Label:    "▶",
+					Style:    discordgo.SecondaryButton,
+					CustomID: customID(page + 1),
+					Disabled: page >= pages-1,
+				},
+			},
+		})
+	}
+
+	return &discordgo.WebhookEdit{
+		Content:    &content,
+		Embeds:     &embeds,
+		Components: &components,
+	}, nil
+}
+
+func renderSources() (*discordgo.WebhookEdit, error) {
+	stats, err := db.NewNewsRepository().ProviderStats()
+	if err != nil {
+		return nil, err
+	}
+
+	statsByProvider := make(map[string]structures.ProviderStats)
+	for _, stat := range stats {
+		statsByProvider[stat.Provider] = stat
+	}
+
+	var description strings.Builder
+	for _, source := range RegisteredSources() {
+		stat := statsByProvider[source.Provider]
+
+		description.WriteString(fmt.Sprintf("**%s** (`%s`) · новостей: %d", source.Provider, source.Name, stat.Count))
+		if !stat.LastAt.IsZero() {
+			description.WriteString(fmt.Sprintf(" · последняя <t:%d:R>", stat.LastAt.Unix()))
+		}
+		description.WriteString("\n")
+	}
+
+	content := ""
+	embeds := []*discordgo.MessageEmbed{
+		{
+			Title:       "Источники новостей",
+			Description: truncateText(description.String(), 4096),
+		},
+	}
+	components := []discordgo.MessageComponent{}
+
+	return &discordgo.WebhookEdit{
+		Content:    &content,
+		Embeds:     &embeds,
+		Components: &components,
+	}, nil
+}
+
+func newsEmbed(news *structures.News, threadURL string) *discordgo.MessageEmbed {
+	embed := &discordgo.MessageEmbed{
+		Title:       truncateText(news.Title, 256),
+		URL:         news.URL,
+		Description: truncateText(news.Description, newsEmbedMaxDesc),
+		Footer: &discordgo.MessageEmbedFooter{
+			Text: news.Provider,
+		},
+		Timestamp: news.CreateAt.Format(time.RFC3339),
+	}
+
+	if len(news.Images) > 0 {
+		embed.Thumbnail = &discordgo.MessageEmbedThumbnail{URL: news.Images[0]}
+	}
+
+	if threadURL != "" {
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  "Обсуждение",
+			Value: threadURL,
+		})
+	}
+
+	return embed
+}
+
+// Описания страницы сокращаются поровну, пока общий текст не уложится в лимит Discord.
+// Заголовки, подписи и поля ограничены и без описаний всегда помещаются
+func fitEmbedDescriptions(embeds []*discordgo.MessageEmbed, news []*structures.News) {
+	if len(embeds) == 0 {
+		return
+	}
+
+	total := 0
+	fixed := 0
+	for _, embed := range embeds {
+		length := embedTextLength(embed)
+		total += length
+		fixed += length - utf8.RuneCountInString(embed.Description)
+	}
+	if total <= discordEmbedsMaxText {
+		return
+	}
+
+	limit := min((discordEmbedsMaxText-fixed)/len(embeds), newsEmbedMaxDesc)
+	for i, embed := range embeds {
+		if limit < 2 {
+			embed.Description = ""
+			continue
+		}
+		embed.Description = truncateText(news[i].Description, limit)
+	}
+}
+
+func embedTextLength(embed *discordgo.MessageEmbed) int {
+	length := utf8.RuneCountInString(embed.Title) + utf8.RuneCountInString(embed.Description)
+	if embed.Footer != nil {
+		length += utf8.RuneCountInString(embed.Footer.Text)
+	}
+	if embed.Author != nil {
+		length += utf8.RuneCountInString(embed.Author.Name)
+	}
+	for _, field := range embed.Fields {
+		length += utf8.RuneCountInString(field.Name) + utf8.RuneCountInString(field.Value)
+	}
+	return length
+}
+
+func discordErrorEdit() *discordgo.WebhookEdit {
+	content := "Не удалось загрузить новости, попробуйте позже"
+	embeds := []*discordgo.MessageEmbed{}
+	components := []discordgo.MessageComponent{}
+
+	return &discordgo.WebhookEdit{
+		Content:    &content,
+		Embeds:     &embeds,
+		Components: &components,
+	}
+}
+
+func truncateText(text string, limit int) string {
+	runes := []rune(strings.TrimSpace(text))
+	if len(runes) <= limit {
+		return string(runes)
+	}
+
+	return strings.TrimSpace(string(runes[:limit-1])) + "…"
+}
@@ -0,0 +1,372 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-nelson/pkg/metrics"
+	"go-nelson/pkg/structures"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+const (
+	discordWebhookRetries     = 3
+	discordWebhookMaxWait     = time.Minute
+	discordWebhookPartsDelay  = 500 * time.Millisecond
+	discordWebhookRequestTime = 60 * time.Second
+)
+
+var discordWebhookClient = &http.Client{Timeout: discordWebhookRequestTime}
+
+type discordWebhookPayload struct {
+	Content     string                     `json:"content"`
+	Username    string                     `json:"username,omitempty"`
+	AvatarURL   string                     `json:"avatar_url,omitempty"`
+	ThreadName  string                     `json:"thread_name,omitempty"`
+	AppliedTags []string                   `json:"applied_tags,omitempty"`
+	Attachments []discordWebhookAttachment `json:"attachments,omitempty"`
+}
+
+type discordWebhookAttachment struct {
+	ID       int    `json:"id"`
+	Filename string `json:"filename"`
+}
+
+type discordWebhookFile struct {
+	name string
+	data []byte
+}
+
+type discordWebhookMessage struct {
+	ID        string `json:"id"`
+	ChannelID string `json:"channel_id"`
+}
+
+// Вебхуки работают без подключения к шлюзу, поэтому запускаются независимо от бота
+func StartDiscordWebhooks(ctx context.Context) {
+	handler := destinationHandler{
+		publish: sendToDiscordWebhook,
+		edit:    editDiscordWebhookNews,
+		delete:  deleteDiscordWebhookNews,
+		mark:    markDiscordWebhookNews,
+	}
+
+	for _, target := range enabledTargets(DestinationDiscordWebhook) {
+		startOutboxWorker(ctx, target, handler)
+	}
+}
+
+func sendToDiscordWebhook(ctx context.Context, target deliveryTarget, news *structures.News, delivery *structures.Delivery, _ string) (string, error) {
+	payload := discordWebhookPayload{
+		Username:  target.Username,
+		AvatarURL: target.AvatarURL,
+	}
+
+	if target.Forum {
+		payload.ThreadName = formatTitle(news.Title)
+		payload.AppliedTags = webhookTags(target, news.Provider)
+		payload.Content = makeDescription(news.URL, news.Description[:min(1800, len(news.Description))], news.Tags, news.Title, news.Provider, len(news.Images) > 0)
+	} else {
+		payload.Content = makeChannelMessage(news, news.Title)
+	}
+
+	file := webhookImage(news)
+
+	message, err := executeDiscordWebhook(ctx, http.MethodPost, target.WebhookURL, "", "", payload, file)
+	if err != nil {
+		return "", fmt.Errorf("ошибка отправки новости через вебхук: %w", err)
+	}
+
+	if target.Forum {
+		delivery.PartMessageIDs = nil
+		syncWebhookDescriptionParts(ctx, target, message.ChannelID, news, delivery)
+	}
+
+	return message.ID, nil
+}
+
+// Продолжение длинного описания отправляется в созданный тред так же, как ботом, и так же
+// обновляется на месте при правке
+func syncWebhookDescriptionParts(ctx context.Context, target deliveryTarget, threadID string, news *structures.News, delivery *structures.Delivery) {
+	var parts []string
+	if len(news.Description) > 1800 {
+		for _, part := range splitLongText(news.Description[1800:], 2000) {
+			if part != "" {
+				parts = append(parts, part)
+			}
+		}
+	}
+
+	previous := delivery.PartMessageIDs
+	ids := make([]string, 0, len(parts))
+
+	for i, part := range parts {
+		payload := discordWebhookPayload{Content: part}
+
+		if i < len(previous) {
+			if _, err := executeDiscordWebhook(ctx, http.MethodPatch, target.WebhookURL, previous[i], threadID, payload, nil); err != nil {
+				slog.Error("Failed to edit description continuation", "destination", target.Name, "news_id", news.Id.Hex(), "error", err)
+			}
+			ids = append(ids, previous[i])
+			continue
+		}
+
+		payload.Username = target.Username
+		payload.AvatarURL = target.AvatarURL
+		message, err := executeDiscordWebhook(ctx, http.MethodPost, target.WebhookURL, "", threadID, payload, nil)
+		if err != nil {
+			slog.Error("Failed to send description continuation", "destination", target.Name, "news_id", news.Id.Hex(), "error", err)
+			continue
+		}
+		ids = append(ids, message.ID)
+
+		// При остановке неотправленные продолжения досылаются следующей правкой
+		select {
+		case <-ctx.Done():
+			delivery.PartMessageIDs = ids
+			return
+		case <-time.After(discordWebhookPartsDelay):
+		}
+	}
+
+	for _, id := range previous[min(len(parts), len(previous)):] {
+		if _, err := executeDiscordWebhook(ctx, http.MethodDelete, target.WebhookURL, id, threadID, nil, nil); err != nil {
+			slog.Error("Failed to delete description continuation", "destination", target.Name, "news_id", news.Id.Hex(), "error", err)
+		}
+	}
+
+	delivery.PartMessageIDs = ids
+}
+
+// Название треда через вебхук изменить нельзя, поэтому обновляется только стартовое сообщение
+func editDiscordWebhookNews(ctx context.Context, target deliveryTarget, news *structures.News, delivery *structures.Delivery, messageID string) (string, error) {
+	if messageID == "" {
+		slog.Info("News not published, skipping edit", "destination", target.Name, "news_id", news.Id.Hex(), "title", news.Title)
+		return "", nil
+	}
+
+	payload := discordWebhookPayload{}
+	if target.Forum {
+		payload.Content = makeDescription(news.URL, news.Description[:min(1800, len(news.Description))], news.Tags, news.Title, news.Provider, len(news.Images) > 0)
+	} else {
+		payload.Content = makeChannelMessage(news, news.Title)
+	}
+
+	_, err := executeDiscordWebhook(ctx, http.MethodPatch, target.WebhookURL, messageID, webhookThreadID(target, messageID), payload, webhookImage(news))
+	if err != nil {
+		return messageID, fmt.Errorf("ошибка при редактировании сообщения вебхука: %w", err)
+	}
+
+	if target.Forum {
+		syncWebhookDescriptionParts(ctx, target, webhookThreadID(target, messageID), news, delivery)
+	}
+
+	slog.Info("News updated", "destination", target.Name, "news_id", news.Id.Hex(), "title", news.Title)
+	return messageID, nil
+}
+
+// Вебхук не может удалить тред форума, удаляется только стартовое сообщение
+func deleteDiscordWebhookNews(ctx context.Context, target deliveryTarget, news *structures.News, _ *structures.Delivery, messageID string) (string, error) {
+	if messageID == "" {
+		return "", nil
+	}
+
+	_, err := executeDiscordWebhook(ctx, http.MethodDelete, target.WebhookURL, messageID, webhookThreadID(target, messageID), nil, nil)
+	if err != nil {
+		return messageID, fmt.Errorf("ошибка при удалении сообщения вебхука: %w", err)
+	}
+
+	slog.Info("News deleted", "destination", target.Name, "news_id", news.Id.Hex(), "title", news.Title)
+	return messageID, nil
+}
+
+func markDiscordWebhookNews(ctx context.Context, target deliveryTarget, news *structures.News, _ *structures.Delivery, messageID string) (string, error) {
+	if messageID == "" {
+		return "", nil
+	}
+
+	title := retractionMarker() + " " + news.Title
+	payload := discordWebhookPayload{}
+	if target.Forum {
+		payload.Content = fmt.Sprintf("**%s**\n\n%s", title, makeDescription(news.URL, news.Description[:min(1500, len(news.Description))], news.Tags, "", news.Provider, len(news.Images) > 0))
+	} else {
+		payload.Content = makeChannelMessage(news, title)
+	}
+
+	_, err := executeDiscordWebhook(ctx, http.MethodPatch, target.WebhookURL, messageID, webhookThreadID(target, messageID), payload, nil)
+	if err != nil {
+		return messageID, fmt.Errorf("ошибка при пометке сообщения вебхука: %w", err)
+	}
+
+	slog.Info("News marked as retracted", "destination", target.Name, "news_id", news.Id.Hex(), "title", news.Title)
+	return messageID, nil
+}
+
+// ID стартового сообщения в треде форума совпадает с ID самого треда
+func webhookThreadID(target deliveryTarget, messageID string) string {
+	if target.Forum {
+		return messageID
+	}
+	return ""
+}
+
+// В отличие от бота вебхук не может искать теги по названию, поэтому в tags указываются ID тегов
+func webhookTags(target deliveryTarget, provider string) []string {
+	tags := append([]string{}, target.AppliedTags...)
+	if tagID, ok := target.Tags[provider]; ok && tagID != "" {
+		tags = append(tags, tagID)
+	}
+	return tags
+}
+
+func webhookImage(news *structures.News) *discordWebhookFile {
+	if len(news.Images) == 0 {
+		return nil
+	}
+
+	imageData, fileName, err := prepareImage(news.Images[0], maxDiscordImageSize)
+	if err != nil {
+		slog.Warn("Failed to prepare image", "error", err)
+		return nil
+	}
+
+	return &discordWebhookFile{name: fileName, data: imageData}
+}
+
+func executeDiscordWebhook(ctx context.Context, method, webhookURL, messageID, threadID string, payload any, file *discordWebhookFile) (*discordWebhookMessage, error) {
+	endpoint, err := url.Parse(webhookURL)
+	if err != nil {
+		return nil, err
+	}
+
+	if messageID != "" {
+		endpoint = endpoint.JoinPath("messages", messageID)
+	}
+
+	query := endpoint.Query()
+	if method == http.MethodPost {
+		query.Set("wait", "true")
+	}
+	if threadID != "" {
+		query.Set("thread_id", threadID)
+	}
+	endpoint.RawQuery = query.Encode()
+
+	// При редактировании список вложений заменяет старые, как и при правке ботом
+	if p, ok := payload.(discordWebhookPayload); ok && file != nil {
+		p.Attachments = []discordWebhookAttachment{{ID: 0, Filename: file.name}}
+		payload = p
+	}
+
+	for attempt := 0; ; attempt++ {
+		body, contentType, err := encodeDiscordWebhookBody(payload, file)
+		if err != nil {
+			return nil, err
+		}
+
+		req, err := http.NewRequestWithContext(ctx, method, endpoint.String(), body)
+		if err != nil {
+			return nil, err
+		}
+		if contentType != "" {
+			req.Header.Set("Content-Type", contentType)
+		}
+
+		resp, err := discordWebhookClient.Do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		data, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		if resp.StatusCode == http.StatusTooManyRequests && attempt < discordWebhookRetries {
+			metrics.RateLimitHits.WithLabelValues(DestinationDiscordWebhook).Inc()
+			wait := discordWebhookRetryAfter(resp, data)
+			slog.Warn("Discord webhook rate limited, retrying", "wait", wait)
+			select {
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			case <-time.After(wait):
+			}
+			continue
+		}
+
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			return nil, fmt.Errorf("вебхук вернул статус %s: %s", resp.Status, truncateText(string(data), 300))
+		}
+
+		message := &discordWebhookMessage{}
+		if len(data) > 0 {
+			if err := json.Unmarshal(data, message); err != nil {
+				return nil, fmt.Errorf("ошибка при разборе ответа вебхука: %w", err)
+			}
+		}
+
+		return message, nil
+	}
+}
+
+func encodeDiscordWebhookBody(payload any, file *discordWebhookFile) (io.Reader, string, error) {
+	if payload == nil {
+		return nil, "", nil
+	}
+
+	payloadJSON, err := json.Marshal(payload)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if file == nil {
+		return bytes.NewReader(payloadJSON), "application/json", nil
+	}
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	if err := writer.WriteField("payload_json", string(payloadJSON)); err != nil {
+		return nil, "", err
+	}
+
+	part, err := writer.CreateFormFile("files[0]", file.name)
+	if err != nil {
+		return nil, "", err
+	}
+	if _, err := part.Write(file.data); err != nil {
+		return nil, "", err
+	}
+
+	if err := writer.Close(); err != nil {
+		return nil, "", err
+	}
+
+	return &body, writer.FormDataContentType(), nil
+}
+
+func discordWebhookRetryAfter(resp *http.Response, data []byte) time.Duration {
+	var body struct {
+		RetryAfter float64 `json:"retry_after"`
+	}
+
+	wait := time.Second
+	if err := json.Unmarshal(data, &body); err == nil && body.RetryAfter > 0 {
+		wait = time.Duration(body.RetryAfter * float64(time.Second))
+	} else if seconds, err := strconv.ParseFloat(resp.Header.Get("Retry-After"), 64); err == nil {
+		wait = time.Duration(seconds * float64(time.Second))
+	}
+
+	if wait > discordWebhookMaxWait {
+		wait = discordWebhookMaxWait
+	}
+	return wait
+}
@@ -0,0 +1,106 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"image/jpeg"
+	"log/slog"
+	"net/http"
+	"path/filepath"
+	"strings"
+
+	"go-nelson/pkg/metrics"
+	"go-nelson/pkg/utils"
+
+	"golang.org/x/image/webp"
+)
+
+func downloadImage(url string) ([]byte, string, string, error) {
+	fetcher := utils.NewFetcher()
+
+	imageData, err := fetcher.Fetch(url)
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	contentType := http.DetectContentType(imageData)
+
+	fileName := filepath.Base(url)
+	if fileName == "" || fileName == "." || strings.Contains(fileName, "?") {
+		ext := getExtensionFromContentType(contentType)
+		fileName = fmt.Sprintf("image%s", ext)
+	}
+
+	return imageData, contentType, fileName, nil
+}
+
+func convertWebpToJpg(webpData []byte) ([]byte, string, string, error) {
+	webpImg, err := webp.Decode(bytes.NewReader(webpData))
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	jpgBuf := new(bytes.Buffer)
+
+	err = jpeg.Encode(jpgBuf, webpImg, &jpeg.Options{Quality: 85})
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	jpgData := jpgBuf.Bytes()
+	fileName := "image.jpg"
+
+	return jpgData, "image/jpeg", fileName, nil
+}
+
+func prepareImage(imageURL string, maxSize int) ([]byte, string, error) {
+	imageData, contentType, fileName, err := downloadImage(imageURL)
+	if err != nil {
+		metrics.ImagePreparationFailures.Inc()
+		return nil, "", fmt.Errorf("ошибка при скачивании изображения: %w", err)
+	}
+
+	if len(imageData) > maxSize {
+		metrics.ImagePreparationFailures.Inc()
+		return nil, "", fmt.Errorf("изображение %s превышает %d байт", imageURL, maxSize)
+	}
+
+	if strings.Contains(contentType, "webp") {
+		convertedData, newContentType, newFileName, err := convertWebpToJpg(imageData)
+		if err != nil {
+			slog.Warn("Failed to convert webp to jpg", "url", imageURL, "error", err)
+			metrics.ImageConversionFailures.Inc()
+		} else {
+			imageData = convertedData
+			contentType = newContentType
+			fileName = newFileName
+		}
+	}
+
+	if strings.Contains(fileName, "?") {
+		ext := getExtensionFromContentType(contentType)
+		fileName = fmt.Sprintf("image%s", ext)
+	}
+
+	if len(imageData) >= maxSize {
+		metrics.ImagePreparationFailures.Inc()
+		return nil, "", fmt.Errorf("изображение %s превышает %d байт", imageURL, maxSize)
+	}
+
+	return imageData, fileName, nil
+}
+
+func getExtensionFromContentType(contentType string) string {
+	switch {
+	case strings.Contains(contentType, "jpeg") || strings.Contains(contentType, "jpg"):
+		return ".jpg"
+	case strings.Contains(contentType, "png"):
+		return ".png"
+	case strings.Contains(contentType, "gif"):
+		return ".gif"
+	case strings.Contains(contentType, "webp"):
+		return ".webp"
+	default:
+		return ".jpg"
+	}
+}
@@ -1,27 +1,112 @@
 package services
 
 import (
+	"context"
+	"fmt"
+	"go-nelson/pkg"
 	"go-nelson/pkg/structures"
-	"log"
+	"log/slog"
+	"sync"
+	"time"
 )
 
-func Start() {
-	log.Println("Запуск всех сервисов")
-	go StartDiscord()
-	go StartTelegram()
-	log.Println("Все сервисы успешно запущены")
+const defaultShutdownTimeout = 30 * time.Second
+
+var (
+	backgroundMu sync.Mutex
+	background   sync.WaitGroup
+	closing      bool
+)
+
+// Start запускает сервисы, которые работают до отмены ctx. Close нужно вызывать после отмены,
+// иначе обработчики очередей не завершатся
+func Start(ctx context.Context) {
+	slog.Info("Starting services")
+	logRoutes()
+	enqueuePendingDeliveries()
+	if pkg.Discord.Enabled {
+		go StartDiscord(ctx)
+	}
+	if pkg.Telegram.Enabled {
+		go StartTelegram(ctx)
+	}
+	StartDiscordWebhooks(ctx)
+	StartWebhooks(ctx)
+	if pkg.Watchlists.Enabled {
+		startWatchlistDigest(ctx)
+	}
+	slog.Info("All services started")
 }
 
-func Close() {
-	log.Println("Остановка всех сервисов")
+// Close дожидается текущих отправок в пределах shutdown_timeout и закрывает соединения.
+// Неотправленные доставки остаются в очереди в базе и уходят после следующего запуска
+func Close() error {
+	slog.Info("Stopping services")
+
+	backgroundMu.Lock()
+	closing = true
+	backgroundMu.Unlock()
+
+	timeout := shutdownTimeout()
+	done := make(chan struct{})
+	go func() {
+		background.Wait()
+		close(done)
+	}()
+
+	var err error
+	select {
+	case <-done:
+	case <-time.After(timeout):
+		err = fmt.Errorf("отправки не завершились за %s, незавершенные доставки будут повторены после перезапуска", timeout)
+	}
+
 	CloseDiscord()
 	CloseTelegram()
-	log.Println("Все сервисы успешно остановлены")
+	slog.Info("All services stopped")
+	return err
 }
 
-func SendNews(news []structures.News) {
-	log.Printf("Отправка %d новостей во все сервисы", len(news))
-	for _, n := range news {
-		go SendNewsToThread(n)
+// goBackground запускает работу, завершения которой Close дожидается перед закрытием соединений.
+// После начала остановки новая работа не запускается
+func goBackground(f func()) {
+	backgroundMu.Lock()
+	defer backgroundMu.Unlock()
+
+	if closing {
+		return
 	}
+
+	background.Add(1)
+	go func() {
+		defer background.Done()
+		f()
+	}()
+}
+
+func shutdownTimeout() time.Duration {
+	if pkg.Delivery.ShutdownTimeout == "" {
+		return defaultShutdownTimeout
+	}
+
+	parsed, err := time.ParseDuration(pkg.Delivery.ShutdownTimeout)
+	if err != nil || parsed <= 0 {
+		slog.Warn("Invalid shutdown timeout, using default", "value", pkg.Delivery.ShutdownTimeout, "default", defaultShutdownTimeout)
+		return defaultShutdownTimeout
+	}
+	return parsed
+}
+
+func SendNews(news []structures.News) {
+	slog.Info("Enqueueing news for delivery", "count", len(news))
+	enqueueDeliveries(news)
+}
+
+func UpdateNews(news []structures.News) {
+	slog.Info("Enqueueing news edits for delivery", "count", len(news))
+	requeueEdits(news)
+}
+
+func RetractNews(news structures.News, action string) {
+	requeueRetraction(news, action)
 }
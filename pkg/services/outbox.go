@@ -0,0 +1,311 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-nelson/pkg"
+	"go-nelson/pkg/db"
+	"go-nelson/pkg/metrics"
+	"go-nelson/pkg/structures"
+	"log/slog"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/qiniu/qmgo"
+)
+
+const (
+	DestinationDiscord        = "discord"
+	DestinationTelegram       = "telegram"
+	DestinationDiscordWebhook = "discord_webhook"
+	DestinationWebhook        = "webhook"
+)
+
+const (
+	defaultDeliveryMaxAttempts  = 10
+	defaultDeliveryPollInterval = 5 * time.Second
+	deliveryBaseBackoff         = 30 * time.Second
+	deliveryMaxBackoff          = 1 * time.Hour
+)
+
+// Отправитель получает ID ранее опубликованного сообщения и возвращает ID нового,
+// если публикация его изменила
+type deliverySender func(ctx context.Context, target deliveryTarget, news *structures.News, delivery *structures.Delivery, messageID string) (string, error)
+
+type destinationHandler struct {
+	publish deliverySender
+	edit    deliverySender
+	delete  deliverySender
+	mark    deliverySender
+}
+
+func enqueueDeliveries(news []structures.News) {
+	deliveryRepo := db.NewDeliveryRepository()
+	newsRepo := db.NewNewsRepository()
+
+	for _, n := range news {
+		if n.Id.IsZero() {
+			slog.Warn("News not saved to database, cannot deliver", "provider", n.Provider, "title", n.Title)
+			continue
+		}
+
+		destinations := routeNews(n)
+		if len(destinations) == 0 {
+			slog.Info("No destinations matched news", "provider", n.Provider, "news_id", n.Id.Hex(), "title", n.Title)
+		}
+
+		enqueued := true
+		for _, destination := range destinations {
+			if err := deliveryRepo.Enqueue(n.Id, destination); err != nil {
+				slog.Error("Failed to enqueue delivery", "provider", n.Provider, "news_id", n.Id.Hex(), "destination", destination, "error", err)
+				enqueued = false
+			}
+		}
+
+		if !enqueued {
+			continue
+		}
+		if err := newsRepo.ClearDeliveryPending(n.Id); err != nil {
+			slog.Error("Failed to clear delivery pending flag", "provider", n.Provider, "news_id", n.Id.Hex(), "error", err)
+		}
+	}
+}
+
+// Постановка в очередь идемпотентна, поэтому повтор для уже частично поставленной новости
+// не создает дубликатов доставки
+func enqueuePendingDeliveries() {
+	pending, err := db.NewNewsRepository().FindDeliveryPending()
+	if err != nil {
+		slog.Error("Failed to load news pending delivery", "error", err)
+		return
+	}
+	if len(pending) == 0 {
+		return
+	}
+
+	slog.Info("Enqueueing news saved without deliveries", "count", len(pending))
+
+	news := make([]structures.News, 0, len(pending))
+	for _, n := range pending {
+		news = append(news, *n)
+	}
+	enqueueDeliveries(news)
+}
+
+func requeueEdits(news []structures.News) {
+	deliveryRepo := db.NewDeliveryRepository()
+
+	for _, n := range news {
+		if n.Id.IsZero() {
+			continue
+		}
+
+		requeued, err := deliveryRepo.RequeueSent(n.Id, structures.DeliveryActionEdit)
+		if err != nil {
+			slog.Error("Failed to enqueue news edit", "provider", n.Provider, "news_id", n.Id.Hex(), "error", err)
+			continue
+		}
+		if requeued > 0 {
+			slog.Info("News changed, edit enqueued", "provider", n.Provider, "news_id", n.Id.Hex(), "title", n.Title, "destinations", requeued)
+		}
+	}
+}
+
+func requeueRetraction(news structures.News, action string) {
+	deliveryRepo := db.NewDeliveryRepository()
+
+	if err := deliveryRepo.CancelPending(news.Id, "новость отозвана источником"); err != nil {
+		slog.Error("Failed to cancel delivery of retracted news", "provider", news.Provider, "news_id", news.Id.Hex(), "error", err)
+	}
+
+	requeued, err := deliveryRepo.RequeueSent(news.Id, action)
+	if err != nil {
+		slog.Error("Failed to enqueue news retraction", "provider", news.Provider, "news_id", news.Id.Hex(), "error", err)
+		return
+	}
+	if requeued > 0 {
+		slog.Info("News retraction enqueued", "provider", news.Provider, "news_id", news.Id.Hex(), "title", news.Title, "action", action, "destinations", requeued)
+	}
+}
+
+func retractionMarker() string {
+	if pkg.Delivery.RetractionMarker != "" {
+		return pkg.Delivery.RetractionMarker
+	}
+	return "[Удалено]"
+}
+
+func startOutboxWorker(ctx context.Context, target deliveryTarget, handler destinationHandler) {
+	pollInterval := defaultDeliveryPollInterval
+	if pkg.Delivery.PollInterval != "" {
+		parsed, err := time.ParseDuration(pkg.Delivery.PollInterval)
+		if err != nil || parsed <= 0 {
+			slog.Warn("Invalid delivery poll interval, using default", "value", pkg.Delivery.PollInterval, "default", pollInterval)
+		} else {
+			pollInterval = parsed
+		}
+	}
+
+	goBackground(func() {
+		runOutboxWorker(ctx, target, pollInterval, handler)
+	})
+}
+
+// Остановка проверяется только между доставками, поэтому начатая отправка всегда доходит до отметки в очереди
+func runOutboxWorker(ctx context.Context, target deliveryTarget, pollInterval time.Duration, handler destinationHandler) {
+	slog.Info("Starting delivery worker", "destination", target.Name)
+
+	deliveryRepo := db.NewDeliveryRepository()
+	newsRepo := db.NewNewsRepository()
+
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			slog.Info("Delivery worker stopped", "destination", target.Name)
+			return
+		case <-ticker.C:
+		}
+
+		deliveries, err := deliveryRepo.FindDue(target.Name, 1)
+		if err != nil {
+			slog.Error("Failed to read delivery queue", "destination", target.Name, "error", err)
+			continue
+		}
+
+		for _, delivery := range deliveries {
+			processDelivery(ctx, deliveryRepo, newsRepo, target, delivery, handler)
+		}
+	}
+}
+
+func processDelivery(ctx context.Context, deliveryRepo *db.DeliveryRepository, newsRepo *db.NewsRepository, target deliveryTarget, delivery *structures.Delivery, handler destinationHandler) {
+	var send deliverySender
+	switch delivery.Action {
+	case "", structures.DeliveryActionPublish:
+		send = handler.publish
+	case structures.DeliveryActionEdit:
+		send = handler.edit
+	case structures.DeliveryActionDelete:
+		send = handler.delete
+	case structures.DeliveryActionMark:
+		send = handler.mark
+	}
+
+	if send == nil {
+		markDeliveryFailed(deliveryRepo, delivery, fmt.Errorf("действие %s не поддерживается для %s", delivery.Action, delivery.Destination))
+		return
+	}
+
+	news, err := newsRepo.FindByID(delivery.NewsID.Hex())
+	if err != nil {
+		if qmgo.IsErrNoDocuments(err) {
+			markDeliveryFailed(deliveryRepo, delivery, fmt.Errorf("новость не найдена"))
+			return
+		}
+		markDeliveryRetry(deliveryRepo, delivery, err)
+		return
+	}
+
+	messageID := delivery.MessageID
+	if messageID == "" {
+		messageID = legacyMessageID(target, news)
+	}
+
+	action := delivery.Action
+	if action == "" {
+		action = structures.DeliveryActionPublish
+	}
+
+	messageID, err = send(ctx, target, news, delivery, messageID)
+	if err != nil && ctx.Err() != nil {
+		// Прерванная остановкой доставка остается в очереди без учета попытки
+		slog.Info("Delivery interrupted by shutdown", "news_id", news.Id.Hex(), "destination", delivery.Destination, "action", action)
+		return
+	}
+	if err != nil {
+		slog.Error("Failed to deliver news", "provider", news.Provider, "news_id", news.Id.Hex(), "destination", delivery.Destination, "action", action, "error", err)
+		metrics.DeliverySends.WithLabelValues(target.Name, target.Type, action, "failure").Inc()
+		// Discord учитывается через событие сессии, а Telegram сообщает об ограничении только ошибкой
+		var tooManyRequests *bot.TooManyRequestsError
+		if errors.As(err, &tooManyRequests) {
+			metrics.RateLimitHits.WithLabelValues(DestinationTelegram).Inc()
+		}
+		markDeliveryRetry(deliveryRepo, delivery, err)
+		return
+	}
+
+	metrics.DeliverySends.WithLabelValues(target.Name, target.Type, action, "success").Inc()
+
+	if action == structures.DeliveryActionPublish {
+		storeLegacyMessageID(newsRepo, target, news, messageID)
+	}
+
+	if err := deliveryRepo.MarkSent(delivery.Id, messageID, delivery.PartMessageIDs); err != nil {
+		slog.Error("Failed to mark delivery as sent", "provider", news.Provider, "news_id", news.Id.Hex(), "destination", delivery.Destination, "error", err)
+	}
+}
+
+// Доставки, созданные до хранения ID сообщений в очереди, ссылаются на ID из самой новости
+func legacyMessageID(target deliveryTarget, news *structures.News) string {
+	switch target.Name {
+	case DestinationDiscord:
+		return news.DiscordThreadID
+	case DestinationTelegram:
+		return news.TelegramMessageID
+	}
+	return ""
+}
+
+func storeLegacyMessageID(newsRepo *db.NewsRepository, target deliveryTarget, news *structures.News, messageID string) {
+	if messageID == "" {
+		return
+	}
+
+	var err error
+	switch target.Name {
+	case DestinationDiscord:
+		// ID стартового сообщения в треде форума совпадает с ID самого треда
+		err = newsRepo.UpdateDiscordInfo(news.Id.Hex(), messageID, messageID)
+	case DestinationTelegram:
+		err = newsRepo.UpdateTelegramInfo(news.Id.Hex(), messageID)
+	}
+
+	if err != nil {
+		slog.Error("Failed to save message ID", "provider", news.Provider, "news_id", news.Id.Hex(), "destination", target.Name, "error", err)
+	}
+}
+
+func markDeliveryRetry(deliveryRepo *db.DeliveryRepository, delivery *structures.Delivery, cause error) {
+	maxAttempts := pkg.Delivery.MaxAttempts
+	if maxAttempts <= 0 {
+		maxAttempts = defaultDeliveryMaxAttempts
+	}
+
+	attempts := delivery.Attempts + 1
+	if attempts >= maxAttempts {
+		markDeliveryFailed(deliveryRepo, delivery, cause)
+		return
+	}
+
+	backoff := deliveryBaseBackoff << (attempts - 1)
+	if backoff > deliveryMaxBackoff || backoff <= 0 {
+		backoff = deliveryMaxBackoff
+	}
+
+	if err := deliveryRepo.MarkRetry(delivery.Id, cause.Error(), time.Now().Add(backoff)); err != nil {
+		slog.Error("Failed to reschedule delivery", "delivery_id", delivery.Id.Hex(), "destination", delivery.Destination, "error", err)
+	}
+}
+
+func markDeliveryFailed(deliveryRepo *db.DeliveryRepository, delivery *structures.Delivery, cause error) {
+	slog.Error("Delivery failed permanently", "delivery_id", delivery.Id.Hex(), "news_id", delivery.NewsID.Hex(),
+		"destination", delivery.Destination, "attempts", delivery.Attempts+1, "error", cause)
+
+	if err := deliveryRepo.MarkFailed(delivery.Id, cause.Error()); err != nil {
+		slog.Error("Failed to mark delivery as failed", "delivery_id", delivery.Id.Hex(), "destination", delivery.Destination, "error", err)
+	}
+}
@@ -0,0 +1,319 @@
+package services
+
+import (
+	"fmt"
+	"go-nelson/pkg"
+	"go-nelson/pkg/structures"
+	"log/slog"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+type deliveryTarget struct {
+	Name      string
+	Type      string
+	ChannelID string
+	GuildID   string
+	Tags      map[string]string
+	Providers []string
+
+	WebhookURL  string
+	Forum       bool
+	AppliedTags []string
+	Username    string
+	AvatarURL   string
+	Secret      string
+}
+
+type newsRoute struct {
+	name         string
+	providers    []string
+	tags         []string
+	title        *regexp.Regexp
+	description  *regexp.Regexp
+	languages    []string
+	destinations []string
+}
+
+var newsRoutes []newsRoute
+
+// Встроенные назначения discord и telegram соответствуют форуму и каналу из основных настроек
+func deliveryTargets() []deliveryTarget {
+	var targets []deliveryTarget
+
+	if pkg.Discord.NewsForumId != "" {
+		targets = append(targets, deliveryTarget{Name: DestinationDiscord, Type: DestinationDiscord, ChannelID: pkg.Discord.NewsForumId, GuildID: pkg.Discord.GuildID})
+	}
+
+	for _, target := range pkg.Discord.Targets {
+		targets = append(targets, deliveryTarget{
+			Name:      target.Name,
+			Type:      DestinationDiscord,
+			ChannelID: target.ChannelID,
+			GuildID:   target.GuildID,
+			Tags:      target.Tags,
+			Providers: resolveProviders(target.Sources),
+		})
+	}
+
+	if pkg.Telegram.ChannelID != "" {
+		targets = append(targets, deliveryTarget{Name: DestinationTelegram, Type: DestinationTelegram, ChannelID: pkg.Telegram.ChannelID})
+	}
+
+	names := make([]string, 0, len(pkg.Routing.Destinations))
+	for name := range pkg.Routing.Destinations {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		destination := pkg.Routing.Destinations[name]
+		targets = append(targets, deliveryTarget{
+			Name:        name,
+			Type:        destination.Type,
+			ChannelID:   destination.ChannelID,
+			Tags:        destination.Tags,
+			WebhookURL:  destination.WebhookURL,
+			Forum:       destination.Forum,
+			AppliedTags: destination.AppliedTags,
+			Username:    destination.Username,
+			AvatarURL:   destination.AvatarURL,
+			Secret:      destination.Secret,
+		})
+	}
+
+	return targets
+}
+
+func isDestinationTypeEnabled(destinationType string) bool {
+	switch destinationType {
+	case DestinationDiscord:
+		return pkg.Discord.Enabled
+	case DestinationTelegram:
+		return pkg.Telegram.Enabled
+	case DestinationDiscordWebhook, DestinationWebhook:
+		return true
+	}
+	return false
+}
+
+func enabledTargets(destinationType string) []deliveryTarget {
+	var targets []deliveryTarget
+
+	for _, target := range deliveryTargets() {
+		if target.Type == destinationType && isDestinationTypeEnabled(target.Type) {
+			targets = append(targets, target)
+		}
+	}
+
+	return targets
+}
+
+func (t deliveryTarget) accepts(news structures.News) bool {
+	return len(t.Providers) == 0 || containsFold(t.Providers, news.Provider)
+}
+
+func ConfigureRouting() error {
+	known := make(map[string]bool)
+	for _, target := range pkg.Discord.Targets {
+		if target.Name == "" {
+			return fmt.Errorf("не указано имя назначения Discord для канала %s", target.ChannelID)
+		}
+		if target.Name == DestinationDiscord || target.Name == DestinationTelegram || known[target.Name] {
+			return fmt.Errorf("имя назначения %s уже занято", target.Name)
+		}
+		if target.ChannelID == "" {
+			return fmt.Errorf("назначение %s: не указан channel_id", target.Name)
+		}
+		known[target.Name] = true
+	}
+
+	for name, destination := range pkg.Routing.Destinations {
+		if name == DestinationDiscord || name == DestinationTelegram || known[name] {
+			return fmt.Errorf("имя назначения %s уже занято", name)
+		}
+		if err := validateDestination(destination); err != nil {
+			return fmt.Errorf("назначение %s: %w", name, err)
+		}
+		known[name] = true
+	}
+	known[DestinationDiscord] = true
+	known[DestinationTelegram] = true
+
+	for _, name := range pkg.Routing.Default {
+		if !known[name] {
+			return fmt.Errorf("неизвестное назначение по умолчанию %s", name)
+		}
+	}
+
+	routes := make([]newsRoute, 0, len(pkg.Routing.Routes))
+	for i, config := range pkg.Routing.Routes {
+		name := config.Name
+		if name == "" {
+			name = fmt.Sprintf("#%d", i+1)
+		}
+
+		if len(config.Destinations) == 0 {
+			return fmt.Errorf("маршрут %s: не указаны назначения", name)
+		}
+		for _, destination := range config.Destinations {
+			if !known[destination] {
+				return fmt.Errorf("маршрут %s: неизвестное назначение %s", name, destination)
+			}
+		}
+
+		route := newsRoute{
+			name:         name,
+			providers:    resolveProviders(config.Providers),
+			tags:         lowerAll(config.Tags),
+			languages:    lowerAll(config.Languages),
+			destinations: config.Destinations,
+		}
+
+		var err error
+		if config.Title != "" {
+			if route.title, err = regexp.Compile(config.Title); err != nil {
+				return fmt.Errorf("маршрут %s: некорректное выражение для заголовка: %w", name, err)
+			}
+		}
+		if config.Description != "" {
+			if route.description, err = regexp.Compile(config.Description); err != nil {
+				return fmt.Errorf("маршрут %s: некорректное выражение для описания: %w", name, err)
+			}
+		}
+
+		routes = append(routes, route)
+	}
+
+	if len(routes) > 0 && len(pkg.Routing.Default) == 0 {
+		slog.Warn("Routing default is empty, news matching no route will not be delivered")
+	}
+
+	newsRoutes = routes
+	return nil
+}
+
+func validateDestination(destination structures.DestinationConfigStruct) error {
+	switch destination.Type {
+	case DestinationDiscord, DestinationTelegram:
+		if destination.ChannelID == "" {
+			return fmt.Errorf("не указан channel_id")
+		}
+	case DestinationDiscordWebhook:
+		parsed, err := url.Parse(destination.WebhookURL)
+		if err != nil || parsed.Scheme != "https" || parsed.Host == "" {
+			return fmt.Errorf("некорректный webhook_url")
+		}
+	case DestinationWebhook:
+		parsed, err := url.Parse(destination.WebhookURL)
+		if err != nil || (parsed.Scheme != "https" && parsed.Scheme != "http") || parsed.Host == "" {
+			return fmt.Errorf("некорректный webhook_url")
+		}
+		if destination.Secret == "" {
+			slog.Warn("Webhook secret not set, requests will not be signed", "url", destination.WebhookURL)
+		}
+	default:
+		return fmt.Errorf("неизвестный тип %q", destination.Type)
+	}
+
+	return nil
+}
+
+// Без маршрутов новость уходит во все включенные назначения, иначе во все назначения
+// подходящих маршрутов, а если ни один не подошел — в назначения по умолчанию
+//
+// Назначения с выбором источников дополнительно отбрасывают новости остальных провайдеров
+func routeNews(news structures.News) []string {
+	enabled := make(map[string]deliveryTarget)
+	var candidates []string
+	for _, target := range deliveryTargets() {
+		if isDestinationTypeEnabled(target.Type) {
+			enabled[target.Name] = target
+			candidates = append(candidates, target.Name)
+		}
+	}
+
+	if len(newsRoutes) > 0 {
+		candidates = nil
+		for _, route := range newsRoutes {
+			if route.matches(news) {
+				candidates = append(candidates, route.destinations...)
+			}
+		}
+
+		if len(candidates) == 0 {
+			candidates = pkg.Routing.Default
+		}
+	}
+
+	seen := make(map[string]bool)
+	var destinations []string
+	for _, destination := range candidates {
+		target, ok := enabled[destination]
+		if ok && target.accepts(news) && !seen[destination] {
+			seen[destination] = true
+			destinations = append(destinations, destination)
+		}
+	}
+
+	return destinations
+}
+
+func (r newsRoute) matches(news structures.News) bool {
+	if len(r.providers) > 0 && !containsFold(r.providers, news.Provider) {
+		return false
+	}
+
+	if len(r.tags) > 0 {
+		matched := false
+		for _, tag := range news.Tags {
+			if containsFold(r.tags, tag) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+
+	if len(r.languages) > 0 && !containsFold(r.languages, news.Language) {
+		return false
+	}
+
+	if r.title != nil && !r.title.MatchString(news.Title) {
+		return false
+	}
+
+	if r.description != nil && !r.description.MatchString(news.Description) {
+		return false
+	}
+
+	return true
+}
+
+func lowerAll(values []string) []string {
+	result := make([]string, 0, len(values))
+	for _, value := range values {
+		result = append(result, strings.ToLower(strings.TrimSpace(value)))
+	}
+	return result
+}
+
+func containsFold(values []string, value string) bool {
+	value = strings.ToLower(strings.TrimSpace(value))
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func logRoutes() {
+	for _, route := range newsRoutes {
+		slog.Info("Route configured", "route", route.name, "destinations", route.destinations)
+	}
+}
@@ -0,0 +1,218 @@
+package services
+
+import (
+	"reflect"
+	"regexp"
+	"testing"
+
+	"go-nelson/pkg"
+	"go-nelson/pkg/structures"
+)
+
+func TestNewsRouteMatches(t *testing.T) {
+	news := structures.News{
+		Provider:    "Epic Games Store",
+		Title:       "Игра Control доступна бесплатно",
+		Description: "Раздача продлится неделю",
+		Tags:        []string{"Раздача", "PC"},
+		Language:    "ru",
+	}
+
+	tests := []struct {
+		name  string
+		route newsRoute
+		want  bool
+	}{
+		{name: "пустой маршрут", route: newsRoute{}, want: true},
+		{name: "провайдер", route: newsRoute{providers: []string{"steam", "epic games store"}}, want: true},
+		{name: "другой провайдер", route: newsRoute{providers: []string{"steam"}}, want: false},
+		{name: "любой из тегов", route: newsRoute{tags: []string{"xbox", "pc"}}, want: true},
+		{name: "нет тега", route: newsRoute{tags: []string{"xbox"}}, want: false},
+		{name: "язык", route: newsRoute{languages: []string{"ru"}}, want: true},
+		{name: "другой язык", route: newsRoute{languages: []string{"en"}}, want: false},
+		{name: "заголовок", route: newsRoute{title: regexp.MustCompile(`(?i)бесплатно`)}, want: true},
+		{name: "заголовок не подходит", route: newsRoute{title: regexp.MustCompile(`скидка`)}, want: false},
+		{name: "описание", route: newsRoute{description: regexp.MustCompile(`недел`)}, want: true},
+		{
+			name: "все условия должны выполняться",
+			route: newsRoute{
+				providers: []string{"epic games store"},
+				tags:      []string{"раздача"},
+				title:     regexp.MustCompile(`скидка`),
+			},
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.route.matches(news); got != tt.want {
+				t.Errorf("matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRouteNews(t *testing.T) {
+	RegisterProvider("routingtest", "Routing Test", "RT")
+
+	baseDiscord := structures.DiscordConfigStruct{
+		Enabled:     true,
+		NewsForumId: "forum",
+		Targets: []structures.DiscordTargetConfigStruct{
+			{Name: "games", ChannelID: "games-channel", Sources: []string{"routingtest"}},
+		},
+	}
+	baseTelegram := structures.TelegramConfigStruct{Enabled: true, ChannelID: "@news"}
+	destinations := map[string]structures.DestinationConfigStruct{
+		"hook": {Type: DestinationWebhook, WebhookURL: "https://example.com/hook", Secret: "secret"},
+	}
+
+	routed := structures.News{Provider: "Routing Test", Title: "Новость"}
+	other := structures.News{Provider: "DTF", Title: "Другая новость"}
+
+	tests := []struct {
+		name     string
+		telegram bool
+		routes   []structures.RouteConfigStruct
+		defaults []string
+		news     structures.News
+		want     []string
+	}{
+		{
+			name:     "без маршрутов во все назначения",
+			telegram: true,
+			news:     routed,
+			want:     []string{DestinationDiscord, "games", DestinationTelegram, "hook"},
+		},
+		{
+			name:     "назначение с выбором источников отбрасывает чужие новости",
+			telegram: true,
+			news:     other,
+			want:     []string{DestinationDiscord, DestinationTelegram, "hook"},
+		},
+		{
+			name: "выключенный тип назначения пропускается",
+			news: other,
+			want: []string{DestinationDiscord, "hook"},
+		},
+		{
+			name:     "провайдер маршрута по имени источника",
+			telegram: true,
+			routes: []structures.RouteConfigStruct{
+				{Providers: []string{"RoutingTest"}, Destinations: []string{"games", "hook"}},
+			},
+			defaults: []string{DestinationTelegram},
+			news:     routed,
+			want:     []string{"games", "hook"},
+		},
+		{
+			name:     "без подходящего маршрута в назначения по умолчанию",
+			telegram: true,
+			routes: []structures.RouteConfigStruct{
+				{Providers: []string{"routingtest"}, Destinations: []string{"games"}},
+			},
+			defaults: []string{DestinationTelegram},
+			news:     other,
+			want:     []string{DestinationTelegram},
+		},
+		{
+			name:     "без подходящего маршрута и назначений по умолчанию",
+			telegram: true,
+			routes: []structures.RouteConfigStruct{
+				{Providers: []string{"routingtest"}, Destinations: []string{"games"}},
+			},
+			news: other,
+			want: nil,
+		},
+		{
+			name:     "назначения нескольких маршрутов не повторяются",
+			telegram: true,
+			routes: []structures.RouteConfigStruct{
+				{Providers: []string{"Routing Test"}, Destinations: []string{"hook", DestinationTelegram}},
+				{Title: "Новость", Destinations: []string{DestinationTelegram, DestinationDiscord}},
+			},
+			news: routed,
+			want: []string{"hook", DestinationTelegram, DestinationDiscord},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			telegram := baseTelegram
+			telegram.Enabled = tt.telegram
+			setRoutingConfig(t, baseDiscord, telegram, structures.RoutingConfigStruct{
+				Destinations: destinations,
+				Routes:       tt.routes,
+				Default:      tt.defaults,
+			})
+
+			if got := routeNews(tt.news); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("routeNews() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigureRoutingErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		routing structures.RoutingConfigStruct
+	}{
+		{
+			name:    "неизвестное назначение маршрута",
+			routing: structures.RoutingConfigStruct{Routes: []structures.RouteConfigStruct{{Destinations: []string{"missing"}}}},
+		},
+		{
+			name:    "маршрут без назначений",
+			routing: structures.RoutingConfigStruct{Routes: []structures.RouteConfigStruct{{Name: "empty"}}},
+		},
+		{
+			name:    "некорректное выражение",
+			routing: structures.RoutingConfigStruct{Routes: []structures.RouteConfigStruct{{Title: "(", Destinations: []string{DestinationDiscord}}}},
+		},
+		{
+			name:    "неизвестное назначение по умолчанию",
+			routing: structures.RoutingConfigStruct{Default: []string{"missing"}},
+		},
+		{
+			name: "занятое имя назначения",
+			routing: structures.RoutingConfigStruct{Destinations: map[string]structures.DestinationConfigStruct{
+				DestinationTelegram: {Type: DestinationTelegram, ChannelID: "@news"},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			restoreRoutingConfig(t)
+			pkg.Discord = structures.DiscordConfigStruct{}
+			pkg.Telegram = structures.TelegramConfigStruct{}
+			pkg.Routing = tt.routing
+
+			if err := ConfigureRouting(); err == nil {
+				t.Error("ConfigureRouting() error = nil, want error")
+			}
+		})
+	}
+}
+
+func setRoutingConfig(t *testing.T, discord structures.DiscordConfigStruct, telegram structures.TelegramConfigStruct, routing structures.RoutingConfigStruct) {
+	t.Helper()
+	restoreRoutingConfig(t)
+
+	pkg.Discord = discord
+	pkg.Telegram = telegram
+	pkg.Routing = routing
+
+	if err := ConfigureRouting(); err != nil {
+		t.Fatalf("ConfigureRouting() error = %v", err)
+	}
+}
+
+func restoreRoutingConfig(t *testing.T) {
+	discord, telegram, routing, routes := pkg.Discord, pkg.Telegram, pkg.Routing, newsRoutes
+	t.Cleanup(func() {
+		pkg.Discord, pkg.Telegram, pkg.Routing, newsRoutes = discord, telegram, routing, routes
+	})
+}
@@ -2,74 +2,83 @@ package services
 
 import (
 	"context"
+	"fmt"
 	"go-nelson/pkg"
-	"log"
+	"log/slog"
+	"sync"
 	"time"
 
 	"github.com/go-telegram/bot"
 )
 
+var telegramBot *bot.Bot
+
 var (
-	telegramBot   *bot.Bot
-	telegramQueue = make(chan string)
+	telegramPollingMu   sync.Mutex
+	stopTelegramPolling context.CancelFunc
 )
 
-func StartTelegram() {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+func StartTelegram(ctx context.Context) {
+	setupCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
 	defer cancel()
 
 	var err error
 	telegramBot, err = bot.New(pkg.Telegram.Token)
 	if err != nil {
-		log.Printf("Ошибка создания Telegram бота: %v", err)
-		log.Println("Проверьте правильность токена, доступ к api.telegram.org и настройки сети")
+		slog.Error("Failed to create Telegram bot, check the token, access to api.telegram.org and network settings", "error", err)
 		return
 	}
 
-	me, err := telegramBot.GetMe(ctx)
+	me, err := telegramBot.GetMe(setupCtx)
 	if err != nil {
-		log.Printf("Ошибка подключения к Telegram API: %v", err)
-		log.Println("Проверьте правильность токена, доступ к api.telegram.org и настройки сети")
+		slog.Error("Failed to connect to Telegram API, check the token, access to api.telegram.org and network settings", "error", err)
 		return
 	}
-	log.Printf("Telegram бот успешно настроен: @%s [ID: %d]", me.Username, me.ID)
-
-	go func() {
-		log.Println("Telegram бот успешно запущен")
-		telegramBot.Start(context.Background())
-	}()
-
-	go handleTelegramQueue()
-}
+	slog.Info("Telegram bot configured", "username", me.Username, "bot_id", me.ID)
+
+	registerTelegramHandlers(setupCtx)
+
+	// Опрос обновлений завершается вместе с контекстом или в CloseTelegram, отправки идут через очередь доставки
+	pollingCtx, stopPolling := context.WithCancel(ctx)
+	telegramPollingMu.Lock()
+	stopTelegramPolling = stopPolling
+	telegramPollingMu.Unlock()
+
+	goBackground(func() {
+		slog.Info("Telegram bot started")
+		telegramBot.Start(pollingCtx)
+		slog.Info("Telegram bot stopped")
+	})
+
+	handler := destinationHandler{
+		publish: sendNewsToTelegram,
+		edit:    editTelegramNews,
+		delete:  deleteTelegramNews,
+		mark:    markTelegramNews,
+	}
 
-func CloseTelegram() {
-	if telegramBot != nil {
-		log.Println("Telegram бот остановлен")
+	for _, target := range enabledTargets(DestinationTelegram) {
+		startOutboxWorker(ctx, target, handler)
 	}
 }
 
-func handleTelegramQueue() {
-	ticker := time.NewTicker(2 * time.Second)
-	defer ticker.Stop()
+func CloseTelegram() {
+	telegramPollingMu.Lock()
+	defer telegramPollingMu.Unlock()
 
-	for range ticker.C {
-		select {
-		case message := <-telegramQueue:
-			sendToTelegram(message)
-		default:
-		}
+	if stopTelegramPolling != nil {
+		stopTelegramPolling()
+		stopTelegramPolling = nil
 	}
 }
 
 func SendTelegramMessage(message string) error {
-	telegramQueue <- message
-	return nil
+	return sendToTelegram(message)
 }
 
-func sendToTelegram(message string) {
+func sendToTelegram(message string) error {
 	if telegramBot == nil || pkg.Telegram.ChannelID == "" {
-		log.Println("Telegram бот не настроен или не указан ID канала")
-		return
+		return fmt.Errorf("telegram бот не настроен или не указан ID канала")
 	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -83,6 +92,8 @@ func sendToTelegram(message string) {
 
 	_, err := telegramBot.SendMessage(ctx, &params)
 	if err != nil {
-		log.Printf("Ошибка отправки сообщения в Telegram: %v", err)
+		return fmt.Errorf("ошибка отправки сообщения в Telegram: %w", err)
 	}
+
+	return nil
 }
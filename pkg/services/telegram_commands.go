@@ -0,0 +1,271 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"go-nelson/pkg/db"
+	"go-nelson/pkg/structures"
+	"html"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+const (
+	telegramListDefaultSize = 5
+	telegramListMaxSize     = 20
+	telegramInlinePageSize  = 20
+)
+
+type telegramCommand struct {
+	name        string
+	description string
+	handler     func(ctx context.Context, b *bot.Bot, message *models.Message, args string)
+}
+
+var telegramCommands []telegramCommand
+
+func init() {
+	registerTelegramCommand("start", "Описание бота и список команд", handleTelegramStart)
+	registerTelegramCommand("latest", "Последние новости: /latest [источник] [количество]", handleTelegramLatest)
+	registerTelegramCommand("search", "Поиск новостей: /search <текст>", handleTelegramSearch)
+	registerTelegramCommand("sources", "Список источников новостей", handleTelegramSources)
+	registerTelegramCommand("free", "Текущие бесплатные раздачи", handleTelegramFree)
+}
+
+func registerTelegramCommand(name, description string, handler func(ctx context.Context, b *bot.Bot, message *models.Message, args string)) {
+	telegramCommands = append(telegramCommands, telegramCommand{
+		name:        name,
+		description: description,
+		handler:     handler,
+	})
+}
+
+func registerTelegramHandlers(ctx context.Context) {
+	commands := make([]models.BotCommand, 0, len(telegramCommands))
+
+	for _, command := range telegramCommands {
+		command := command
+		telegramBot.RegisterHandlerMatchFunc(func(update *models.Update) bool {
+			name, _ := parseTelegramCommand(update)
+			return name == command.name
+		}, func(ctx context.Context, b *bot.Bot, update *models.Update) {
+			_, args := parseTelegramCommand(update)
+			command.handler(ctx, b, update.Message, args)
+		})
+
+		commands = append(commands, models.BotCommand{Command: command.name, Description: command.description})
+	}
+
+	telegramBot.RegisterHandlerMatchFunc(func(update *models.Update) bool {
+		return update.InlineQuery != nil
+	}, handleTelegramInlineQuery)
+
+	if _, err := telegramBot.SetMyCommands(ctx, &bot.SetMyCommandsParams{Commands: commands}); err != nil {
+		slog.Error("Failed to set Telegram commands", "error", err)
+	}
+}
+
+// Команда может быть адресована боту явно: /latest@nelson_bot DTF
+func parseTelegramCommand(update *models.Update) (string, string) {
+	if update.Message == nil || !strings.HasPrefix(update.Message.Text, "/") {
+		return "", ""
+	}
+
+	command, args, _ := strings.Cut(update.Message.Text[1:], " ")
+	command, _, _ = strings.Cut(command, "@")
+
+	return strings.ToLower(command), strings.TrimSpace(args)
+}
+
+func handleTelegramStart(ctx context.Context, b *bot.Bot, message *models.Message, _ string) {
+	var text strings.Builder
+	text.WriteString("<b>Nelson</b> собирает новости игровой индустрии.\n\n")
+	for _, command := range telegramCommands {
+		text.WriteString(fmt.Sprintf("/%s — %s\n", command.name, html.EscapeString(command.description)))
+	}
+	text.WriteString("\nВ любом чате можно искать новости через @имя_бота и запрос.")
+
+	replyTelegram(ctx, b, message, text.String())
+}
+
+func handleTelegramLatest(ctx context.Context, b *bot.Bot, message *models.Message, args string) {
+	fields := strings.Fields(args)
+	count := telegramListDefaultSize
+
+	if len(fields) > 0 {
+		if parsed, err := strconv.Atoi(fields[len(fields)-1]); err == nil {
+			count = min(max(parsed, 1), telegramListMaxSize)
+			fields = fields[:len(fields)-1]
+		}
+	}
+
+	var filter db.NewsFilter
+	title := "Последние новости"
+	if len(fields) > 0 {
+		provider, ok := FindProvider(strings.Join(fields, " "))
+		if !ok {
+			replyTelegram(ctx, b, message, "Источник не найден, список источников: /sources")
+			return
+		}
+		filter.Provider = provider
+		title += " " + provider
+	}
+
+	replyNewsList(ctx, b, message, title, filter, count)
+}
+
+func handleTelegramSearch(ctx context.Context, b *bot.Bot, message *models.Message, args string) {
+	if args == "" {
+		replyTelegram(ctx, b, message, "Укажите текст для поиска: /search <текст>")
+		return
+	}
+
+	replyNewsList(ctx, b, message, "Поиск: "+args, db.NewsFilter{Query: args}, telegramListDefaultSize)
+}
+
+func handleTelegramFree(ctx context.Context, b *bot.Bot, message *models.Message, _ string) {
+	replyNewsList(ctx, b, message, "Бесплатные раздачи", db.NewsFilter{ActiveOffers: true}, telegramListMaxSize)
+}
+
+func handleTelegramSources(ctx context.Context, b *bot.Bot, message *models.Message, _ string) {
+	stats, err := db.NewNewsRepository().ProviderStats()
+	if err != nil {
+		slog.Error("Failed to get source statistics", "error", err)
+		replyTelegram(ctx, b, message, "Не удалось загрузить источники, попробуйте позже")
+		return
+	}
+
+	statsByProvider := make(map[string]structures.ProviderStats)
+	for _, stat := range stats {
+		statsByProvider[stat.Provider] = stat
+	}
+
+	var text strings.Builder
+	text.WriteString("<b>Источники новостей</b>\n\n")
+	for _, source := range RegisteredSources() {
+		stat := statsByProvider[source.Provider]
+
+		text.WriteString(fmt.Sprintf("<b>%s</b> (<code>%s</code>) · новостей: %d",
+			html.EscapeString(source.Provider), html.EscapeString(source.Name), stat.Count))
+		if !stat.LastAt.IsZero() {
+			text.WriteString(" · последняя " + stat.LastAt.Format("02.01.2006 15:04"))
+		}
+		text.WriteString("\n")
+	}
+
+	replyTelegram(ctx, b, message, text.String())
+}
+
+func replyNewsList(ctx context.Context, b *bot.Bot, message *models.Message, title string, filter db.NewsFilter, count int) {
+	news, err := db.NewNewsRepository().Find(filter, 0, int64(count))
+	if err != nil {
+		slog.Error("Failed to search news for Telegram", "error", err)
+		replyTelegram(ctx, b, message, "Не удалось загрузить новости, попробуйте позже")
+		return
+	}
+
+	var text strings.Builder
+	text.WriteString("<b>" + html.EscapeString(title) + "</b>\n\n")
+
+	if len(news) == 0 {
+		text.WriteString("Ничего не найдено")
+	}
+
+	for i, n := range news {
+		text.WriteString(fmt.Sprintf("%d. <a href=\"%s\">%s</a> · %s · %s\n",
+			i+1, html.EscapeString(n.URL), html.EscapeString(n.Title),
+			html.EscapeString(n.Provider), n.CreateAt.Format("02.01.2006")))
+	}
+
+	replyTelegram(ctx, b, message, text.String())
+}
+
+func replyTelegram(ctx context.Context, b *bot.Bot, message *models.Message, text string) {
+	if message == nil {
+		return
+	}
+
+	disabled := true
+	_, err := b.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:             message.Chat.ID,
+		Text:               truncateTelegramHTML(text),
+		ParseMode:          models.ParseModeHTML,
+		LinkPreviewOptions: &models.LinkPreviewOptions{IsDisabled: &disabled},
+	})
+	if err != nil {
+		slog.Error("Failed to reply in Telegram", "error", err)
+	}
+}
+
+// Список обрезается по строкам, чтобы не разорвать HTML-разметку
+func truncateTelegramHTML(text string) string {
+	if telegramLength(text) <= telegramMessageLimit {
+		return text
+	}
+
+	var result strings.Builder
+	for _, line := range strings.SplitAfter(text, "\n") {
+		if telegramLength(result.String()+line) > telegramMessageLimit-1 {
+			break
+		}
+		result.WriteString(line)
+	}
+
+	return result.String() + "…"
+}
+
+func handleTelegramInlineQuery(ctx context.Context, b *bot.Bot, update *models.Update) {
+	query := update.InlineQuery
+
+	offset, _ := strconv.Atoi(query.Offset)
+	filter := db.NewsFilter{Query: strings.TrimSpace(query.Query)}
+
+	news, err := db.NewNewsRepository().Find(filter, int64(offset), telegramInlinePageSize)
+	if err != nil {
+		slog.Error("Failed to search news for inline query", "error", err)
+		return
+	}
+
+	results := make([]models.InlineQueryResult, 0, len(news))
+	for _, n := range news {
+		result := &models.InlineQueryResultArticle{
+			ID:          n.Id.Hex(),
+			Title:       n.Title,
+			Description: fmt.Sprintf("%s · %s", n.Provider, truncateTelegramText(n.Description, 100)),
+			URL:         n.URL,
+			InputMessageContent: &models.InputTextMessageContent{
+				MessageText:        formatTelegramPost(n, telegramMessageLimit),
+				ParseMode:          models.ParseModeHTML,
+				LinkPreviewOptions: telegramLinkPreview(n.URL),
+			},
+		}
+		if len(n.Images) > 0 {
+			result.ThumbnailURL = n.Images[0]
+		}
+
+		results = append(results, result)
+	}
+
+	nextOffset := ""
+	if len(news) == telegramInlinePageSize {
+		nextOffset = strconv.Itoa(offset + telegramInlinePageSize)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	_, err = b.AnswerInlineQuery(ctx, &bot.AnswerInlineQueryParams{
+		InlineQueryID: query.ID,
+		Results:       results,
+		CacheTime:     60,
+		NextOffset:    nextOffset,
+	})
+	if err != nil {
+		slog.Error("Failed to answer inline query", "error", err)
+	}
+}
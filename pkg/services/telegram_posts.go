@@ -0,0 +1,430 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"go-nelson/pkg/structures"
+	"html"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf16"
+
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+const (
+	telegramCaptionLimit   = 1024
+	telegramMessageLimit   = 4096
+	telegramMediaGroupSize = 10
+	maxTelegramImageSize   = 10 * 1024 * 1024
+)
+
+func sendNewsToTelegram(ctx context.Context, target deliveryTarget, news *structures.News, delivery *structures.Delivery, _ string) (string, error) {
+	if telegramBot == nil || target.ChannelID == "" {
+		return "", fmt.Errorf("telegram бот не настроен или не указан ID канала")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	messages, err := publishTelegramPost(ctx, target.ChannelID, news)
+	if err != nil {
+		return "", err
+	}
+
+	// Альбом состоит из нескольких сообщений: подпись у первого, ID остальных хранятся отдельно,
+	// чтобы удалять и помечать альбом целиком
+	delivery.PartMessageIDs = make([]string, 0, len(messages)-1)
+	for _, message := range messages[1:] {
+		delivery.PartMessageIDs = append(delivery.PartMessageIDs, strconv.Itoa(message.ID))
+	}
+
+	return strconv.Itoa(messages[0].ID), nil
+}
+
+// Возвращает все отправленные сообщения, первое из них содержит текст поста
+func publishTelegramPost(ctx context.Context, chatID string, news *structures.News) ([]*models.Message, error) {
+	switch {
+	case len(news.Images) > 1:
+		messages, err := sendTelegramMediaGroup(ctx, chatID, news)
+		if err == nil {
+			return messages, nil
+		}
+		slog.Warn("Failed to send Telegram album, falling back to single photo", "news_id", news.Id.Hex(), "error", err)
+		fallthrough
+	case len(news.Images) == 1:
+		message, err := sendTelegramPhoto(ctx, chatID, news)
+		if err == nil {
+			return []*models.Message{message}, nil
+		}
+		slog.Warn("Failed to send Telegram photo, falling back to text", "news_id", news.Id.Hex(), "error", err)
+	}
+
+	message, err := sendTelegramText(ctx, chatID, news)
+	if err != nil {
+		return nil, err
+	}
+	return []*models.Message{message}, nil
+}
+
+func sendTelegramPhoto(ctx context.Context, chatID string, news *structures.News) (*models.Message, error) {
+	imageData, fileName, err := prepareImage(news.Images[0], maxTelegramImageSize)
+	if err != nil {
+		return nil, err
+	}
+
+	return telegramBot.SendPhoto(ctx, &bot.SendPhotoParams{
+		ChatID: chatID,
+		Photo: &models.InputFileUpload{
+			Filename: fileName,
+			Data:     bytes.NewReader(imageData),
+		},
+		Caption:   formatTelegramPost(news, telegramCaptionLimit),
+		ParseMode: models.ParseModeHTML,
+	})
+}
+
+func sendTelegramMediaGroup(ctx context.Context, chatID string, news *structures.News) ([]*models.Message, error) {
+	var media []models.InputMedia
+
+	for i, imageURL := range news.Images {
+		if len(media) == telegramMediaGroupSize {
+			break
+		}
+
+		imageData, _, err := prepareImage(imageURL, maxTelegramImageSize)
+		if err != nil {
+			slog.Warn("Image skipped in Telegram album", "url", imageURL, "error", err)
+			continue
+		}
+
+		photo := &models.InputMediaPhoto{
+			Media:           fmt.Sprintf("attach://image%d", i),
+			MediaAttachment: bytes.NewReader(imageData),
+		}
+		if len(media) == 0 {
+			photo.Caption = formatTelegramPost(news, telegramCaptionLimit)
+			photo.ParseMode = models.ParseModeHTML
+		}
+
+		media = append(media, photo)
+	}
+
+	if len(media) < 2 {
+		return nil, fmt.Errorf("недостаточно изображений для альбома")
+	}
+
+	messages, err := telegramBot.SendMediaGroup(ctx, &bot.SendMediaGroupParams{
+		ChatID: chatID,
+		Media:  media,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(messages) == 0 {
+		return nil, fmt.Errorf("telegram не вернул сообщения альбома")
+	}
+
+	return messages, nil
+}
+
+func sendTelegramText(ctx context.Context, chatID string, news *structures.News) (*models.Message, error) {
+	return telegramBot.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:             chatID,
+		Text:               formatTelegramPost(news, telegramMessageLimit),
+		ParseMode:          models.ParseModeHTML,
+		LinkPreviewOptions: telegramLinkPreview(news.URL),
+	})
+}
+
+func telegramLinkPreview(url string) *models.LinkPreviewOptions {
+	preferLargeMedia := true
+
+	return &models.LinkPreviewOptions{
+		URL:              &url,
+		PreferLargeMedia: &preferLargeMedia,
+	}
+}
+
+func editTelegramNews(ctx context.Context, target deliveryTarget, news *structures.News, delivery *structures.Delivery, messageID string) (string, error) {
+	if telegramBot == nil || target.ChannelID == "" {
+		return "", fmt.Errorf("telegram бот не настроен или не указан ID канала")
+	}
+
+	chatID := target.ChannelID
+
+	if messageID == "" {
+		slog.Info("News not published, skipping edit", "destination", target.Name, "news_id", news.Id.Hex(), "title", news.Title)
+		return "", nil
+	}
+
+	telegramMessageID, err := strconv.Atoi(messageID)
+	if err != nil {
+		return "", fmt.Errorf("некорректный ID сообщения Telegram %s: %w", messageID, err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 60*time.Second)
+	defer cancel()
+
+	// Тип исходного поста не хранится: пробуем заменить фото, затем текст, затем подпись
+	if len(news.Images) > 0 {
+		imageData, _, err := prepareImage(news.Images[0], maxTelegramImageSize)
+		if err == nil {
+			_, err = telegramBot.EditMessageMedia(ctx, &bot.EditMessageMediaParams{
+				ChatID:    chatID,
+				MessageID: telegramMessageID,
+				Media: &models.InputMediaPhoto{
+					Media:           "attach://image",
+					MediaAttachment: bytes.NewReader(imageData),
+					Caption:         formatTelegramPost(news, telegramCaptionLimit),
+					ParseMode:       models.ParseModeHTML,
+				},
+			})
+			if err == nil || isTelegramNotModified(err) {
+				editTelegramAlbumPhotos(ctx, target, news, delivery)
+				return messageID, nil
+			}
+		}
+	}
+
+	_, err = telegramBot.EditMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:             chatID,
+		MessageID:          telegramMessageID,
+		Text:               formatTelegramPost(news, telegramMessageLimit),
+		ParseMode:          models.ParseModeHTML,
+		LinkPreviewOptions: telegramLinkPreview(news.URL),
+	})
+	if err == nil || isTelegramNotModified(err) {
+		return messageID, nil
+	}
+
+	_, err = telegramBot.EditMessageCaption(ctx, &bot.EditMessageCaptionParams{
+		ChatID:    chatID,
+		MessageID: telegramMessageID,
+		Caption:   formatTelegramPost(news, telegramCaptionLimit),
+		ParseMode: models.ParseModeHTML,
+	})
+	if err == nil || isTelegramNotModified(err) {
+		return messageID, nil
+	}
+
+	return messageID, fmt.Errorf("ошибка при редактировании сообщения Telegram: %w", err)
+}
+
+// Остальные фото альбома заменяются по порядку, число фото в уже отправленном альбоме не меняется
+func editTelegramAlbumPhotos(ctx context.Context, target deliveryTarget, news *structures.News, delivery *structures.Delivery) {
+	for i, partID := range telegramPartMessageIDs(delivery) {
+		if i+1 >= len(news.Images) {
+			break
+		}
+
+		imageData, _, err := prepareImage(news.Images[i+1], maxTelegramImageSize)
+		if err != nil {
+			slog.Warn("Image skipped in Telegram album", "url", news.Images[i+1], "error", err)
+			continue
+		}
+
+		_, err = telegramBot.EditMessageMedia(ctx, &bot.EditMessageMediaParams{
+			ChatID:    target.ChannelID,
+			MessageID: partID,
+			Media: &models.InputMediaPhoto{
+				Media:           "attach://image",
+				MediaAttachment: bytes.NewReader(imageData),
+			},
+		})
+		if err != nil && !isTelegramNotModified(err) {
+			slog.Error("Failed to edit Telegram album photo", "destination", target.Name, "news_id", news.Id.Hex(), "error", err)
+		}
+	}
+}
+
+func telegramPartMessageIDs(delivery *structures.Delivery) []int {
+	ids := make([]int, 0, len(delivery.PartMessageIDs))
+	for _, id := range delivery.PartMessageIDs {
+		if parsed, err := strconv.Atoi(id); err == nil {
+			ids = append(ids, parsed)
+		}
+	}
+	return ids
+}
+
+func deleteTelegramNews(ctx context.Context, target deliveryTarget, news *structures.News, delivery *structures.Delivery, messageID string) (string, error) {
+	if telegramBot == nil || target.ChannelID == "" {
+		return "", fmt.Errorf("telegram бот не настроен или не указан ID канала")
+	}
+
+	chatID := target.ChannelID
+
+	if messageID == "" {
+		return "", nil
+	}
+
+	telegramMessageID, err := strconv.Atoi(messageID)
+	if err != nil {
+		return "", fmt.Errorf("некорректный ID сообщения Telegram %s: %w", messageID, err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	_, err = telegramBot.DeleteMessages(ctx, &bot.DeleteMessagesParams{
+		ChatID:     chatID,
+		MessageIDs: append([]int{telegramMessageID}, telegramPartMessageIDs(delivery)...),
+	})
+	if err != nil {
+		return messageID, fmt.Errorf("ошибка при удалении сообщения Telegram: %w", err)
+	}
+
+	slog.Info("News deleted", "destination", target.Name, "news_id", news.Id.Hex(), "title", news.Title)
+	return messageID, nil
+}
+
+func markTelegramNews(ctx context.Context, target deliveryTarget, news *structures.News, delivery *structures.Delivery, messageID string) (string, error) {
+	if telegramBot == nil || target.ChannelID == "" {
+		return "", fmt.Errorf("telegram бот не настроен или не указан ID канала")
+	}
+
+	chatID := target.ChannelID
+
+	if messageID == "" {
+		return "", nil
+	}
+
+	telegramMessageID, err := strconv.Atoi(messageID)
+	if err != nil {
+		return "", fmt.Errorf("некорректный ID сообщения Telegram %s: %w", messageID, err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 30*time.Second)
+	defer cancel()
+
+	marked := *news
+	marked.Title = retractionMarker() + " " + news.Title
+
+	_, err = telegramBot.EditMessageText(ctx, &bot.EditMessageTextParams{
+		ChatID:             chatID,
+		MessageID:          telegramMessageID,
+		Text:               formatTelegramPost(&marked, telegramMessageLimit),
+		ParseMode:          models.ParseModeHTML,
+		LinkPreviewOptions: telegramLinkPreview(news.URL),
+	})
+	if err == nil || isTelegramNotModified(err) {
+		return messageID, nil
+	}
+
+	_, err = telegramBot.EditMessageCaption(ctx, &bot.EditMessageCaptionParams{
+		ChatID:    chatID,
+		MessageID: telegramMessageID,
+		Caption:   formatTelegramPost(&marked, telegramCaptionLimit),
+		ParseMode: models.ParseModeHTML,
+	})
+	if err == nil || isTelegramNotModified(err) {
+		// Фото альбома без подписи помечаются отдельно, чтобы в канале не оставалось неотмеченных фото
+		for _, partID := range telegramPartMessageIDs(delivery) {
+			_, err := telegramBot.EditMessageCaption(ctx, &bot.EditMessageCaptionParams{
+				ChatID:    chatID,
+				MessageID: partID,
+				Caption:   retractionMarker(),
+			})
+			if err != nil && !isTelegramNotModified(err) {
+				slog.Error("Failed to mark Telegram album photo", "destination", target.Name, "news_id", news.Id.Hex(), "error", err)
+			}
+		}
+
+		slog.Info("News marked as retracted", "destination", target.Name, "news_id", news.Id.Hex(), "title", news.Title)
+		return messageID, nil
+	}
+
+	return messageID, fmt.Errorf("ошибка при пометке сообщения Telegram: %w", err)
+}
+
+func isTelegramNotModified(err error) bool {
+	return strings.Contains(err.Error(), "message is not modified")
+}
+
+// Лимиты Telegram считаются в UTF-16 символах видимого текста, поэтому
+// длина считается по тексту без HTML-разметки.
+func formatTelegramPost(news *structures.News, limit int) string {
+	title := strings.TrimSpace(news.Title)
+	description := strings.TrimSpace(news.Description)
+
+	footerPlain := "\n\nПодробнее · " + news.Provider
+	footerHTML := fmt.Sprintf("\n\n<a href=\"%s\">Подробнее</a> · %s",
+		html.EscapeString(news.URL), html.EscapeString(news.Provider))
+
+	if hashtags := formatHashtags(news.Tags); hashtags != "" {
+		footerPlain += "\n" + hashtags
+		footerHTML += "\n" + html.EscapeString(hashtags)
+	}
+
+	titleBudget := limit - telegramLength(footerPlain)
+	title = truncateTelegramText(title, titleBudget)
+
+	descriptionBudget := titleBudget - telegramLength(title) - 2
+	description = truncateTelegramText(description, descriptionBudget)
+
+	var result strings.Builder
+	result.WriteString("<b>" + html.EscapeString(title) + "</b>")
+	if description != "" {
+		result.WriteString("\n\n" + html.EscapeString(description))
+	}
+	result.WriteString(footerHTML)
+
+	return result.String()
+}
+
+func formatHashtags(tags []string) string {
+	var hashtags []string
+
+	for _, tag := range tags {
+		hashtag := strings.Map(func(r rune) rune {
+			if unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' {
+				return r
+			}
+			return '_'
+		}, strings.TrimSpace(tag))
+
+		hashtag = strings.Trim(hashtag, "_")
+		if hashtag != "" {
+			hashtags = append(hashtags, "#"+hashtag)
+		}
+	}
+
+	return strings.Join(hashtags, " ")
+}
+
+func telegramLength(text string) int {
+	return len(utf16.Encode([]rune(text)))
+}
+
+func truncateTelegramText(text string, limit int) string {
+	if limit <= 0 {
+		return ""
+	}
+
+	if telegramLength(text) <= limit {
+		return text
+	}
+
+	var result strings.Builder
+	length := 0
+	for _, r := range text {
+		runeLength := utf16.RuneLen(r)
+		if runeLength < 0 {
+			runeLength = 1
+		}
+		if length+runeLength > limit-1 {
+			break
+		}
+		result.WriteRune(r)
+		length += runeLength
+	}
+
+	return strings.TrimRightFunc(result.String(), unicode.IsSpace) + "…"
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"html"
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	"go-nelson/pkg/structures"
+)
+
+func TestTruncateTelegramText(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		limit int
+		want  string
+	}{
+		{name: "короче лимита", text: "Новость", limit: 10, want: "Новость"},
+		{name: "ровно лимит", text: "Новость", limit: 7, want: "Новость"},
+		{name: "обрезка с многоточием", text: "Новость дня", limit: 8, want: "Новость…"},
+		{name: "пробел перед многоточием убирается", text: "Новость дня", limit: 9, want: "Новость…"},
+		{name: "эмодзи занимает два символа UTF-16", text: "ab😀cd", limit: 4, want: "ab…"},
+		{name: "нулевой лимит", text: "Новость", limit: 0, want: ""},
+		{name: "отрицательный лимит", text: "Новость", limit: -5, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := truncateTelegramText(tt.text, tt.limit)
+			if got != tt.want {
+				t.Errorf("truncateTelegramText() = %q, want %q", got, tt.want)
+			}
+			if telegramLength(got) > max(tt.limit, 0) {
+				t.Errorf("truncateTelegramText() length = %d, want at most %d", telegramLength(got), tt.limit)
+			}
+		})
+	}
+}
+
+func TestFormatHashtags(t *testing.T) {
+	tests := []struct {
+		name string
+		tags []string
+		want string
+	}{
+		{name: "без тегов", tags: nil, want: ""},
+		{name: "простые теги", tags: []string{"игры", "PC"}, want: "#игры #PC"},
+		{name: "пробелы и знаки заменяются", tags: []string{" Epic Games ", "Half-Life 3"}, want: "#Epic_Games #Half_Life_3"},
+		{name: "пустые теги пропускаются", tags: []string{"", "!!!", "steam"}, want: "#steam"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := formatHashtags(tt.tags); got != tt.want {
+				t.Errorf("formatHashtags() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+var telegramMarkup = regexp.MustCompile(`<[^>]+>`)
+
+func TestFormatTelegramPostFitsLimit(t *testing.T) {
+	tests := []struct {
+		name  string
+		news  structures.News
+		limit int
+	}{
+		{
+			name: "короткая новость",
+			news: structures.News{
+				Title:       "Заголовок <важно>",
+				Description: "Описание & подробности",
+				URL:         "https://example.com/?a=1&b=2",
+				Provider:    "DTF",
+				Tags:        []string{"игры"},
+			},
+			limit: telegramCaptionLimit,
+		},
+		{
+			name: "длинное описание в подписи",
+			news: structures.News{
+				Title:       "Заголовок",
+				Description: strings.Repeat("Очень длинное описание 😀 ", 200),
+				URL:         "https://example.com/news",
+				Provider:    "StopGame",
+			},
+			limit: telegramCaptionLimit,
+		},
+		{
+			name: "длинное описание в сообщении",
+			news: structures.News{
+				Title:       "Заголовок",
+				Description: strings.Repeat("Текст & разметка <b> ", 500),
+				URL:         "https://example.com/news",
+				Provider:    "3DNews",
+				Tags:        []string{"железо", "обзоры"},
+			},
+			limit: telegramMessageLimit,
+		},
+		{
+			name: "заголовок длиннее лимита",
+			news: structures.News{
+				Title:       strings.Repeat("Заголовок ", 200),
+				Description: "Описание",
+				URL:         "https://example.com/news",
+				Provider:    "DTF",
+			},
+			limit: telegramCaptionLimit,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			post := formatTelegramPost(&tt.news, tt.limit)
+
+			visible := html.UnescapeString(telegramMarkup.ReplaceAllString(post, ""))
+			if length := telegramLength(visible); length > tt.limit {
+				t.Errorf("formatTelegramPost() visible length = %d, want at most %d", length, tt.limit)
+			}
+
+			link := `<a href="` + html.EscapeString(tt.news.URL) + `">Подробнее</a> · ` + html.EscapeString(tt.news.Provider)
+			if !strings.Contains(post, link) {
+				t.Errorf("formatTelegramPost() = %q, want link %q", post, link)
+			}
+		})
+	}
+}
+
+func TestTelegramPartMessageIDs(t *testing.T) {
+	tests := []struct {
+		name string
+		ids  []string
+		want []int
+	}{
+		{name: "без продолжений", ids: nil, want: []int{}},
+		{name: "ID альбома", ids: []string{"101", "102", "103"}, want: []int{101, 102, 103}},
+		{name: "некорректные ID пропускаются", ids: []string{"101", "", "abc", "104"}, want: []int{101, 104}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := telegramPartMessageIDs(&structures.Delivery{PartMessageIDs: tt.ids})
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("telegramPartMessageIDs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
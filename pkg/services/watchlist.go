@@ -0,0 +1,446 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"go-nelson/pkg"
+	"go-nelson/pkg/db"
+	"go-nelson/pkg/structures"
+	"html"
+	"log/slog"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	defaultWatchlistRateLimit = 10
+	defaultWatchlistMaxRules  = 50
+	defaultWatchlistDigest    = "09:00"
+	maxWatchRuleLength        = 200
+	watchlistRateWindow       = time.Hour
+)
+
+var (
+	watchlistMu sync.Mutex
+
+	watchRegexMu    sync.Mutex
+	watchRegexCache = make(map[string]*regexp.Regexp)
+)
+
+func NotifyWatchers(news []structures.News) {
+	if !pkg.Watchlists.Enabled || len(news) == 0 {
+		return
+	}
+
+	goBackground(func() {
+		notifyWatchers(news)
+	})
+}
+
+// Совпадения сверх лимита в час и совпадения пользователей с включенной сводкой
+// откладываются и уходят одним сообщением в ежедневной сводке
+func notifyWatchers(news []structures.News) {
+	watchlistMu.Lock()
+	defer watchlistMu.Unlock()
+
+	watchlistRepo := db.NewWatchlistRepository()
+
+	watchlists, err := watchlistRepo.FindAll()
+	if err != nil {
+		slog.Error("Failed to load watchlists", "error", err)
+		return
+	}
+
+	limit := pkg.Watchlists.RateLimit
+	if limit <= 0 {
+		limit = defaultWatchlistRateLimit
+	}
+
+	for _, watchlist := range watchlists {
+		matched := matchWatchlist(watchlist, news)
+		if len(matched) == 0 {
+			continue
+		}
+
+		if watchlist.Digest {
+			addWatchlistPending(watchlistRepo, watchlist, matched)
+			continue
+		}
+
+		now := time.Now()
+		windowStart, windowCount := watchlist.WindowStart, watchlist.WindowCount
+		if now.Sub(windowStart) >= watchlistRateWindow {
+			windowStart, windowCount = now, 0
+		}
+
+		var deferred []*structures.News
+		for _, n := range matched {
+			if windowCount >= limit {
+				deferred = append(deferred, n)
+				continue
+			}
+
+			if err := sendWatchNotification(watchlist, n); err != nil {
+				slog.Error("Failed to send watchlist notification", "platform", watchlist.Platform, "user_id", watchlist.UserID, "news_id", n.Id.Hex(), "error", err)
+				deferred = append(deferred, n)
+				continue
+			}
+			windowCount++
+		}
+
+		if err := watchlistRepo.RecordNotification(watchlist.Id, windowStart, windowCount); err != nil {
+			slog.Error("Failed to save notification rate limit", "platform", watchlist.Platform, "user_id", watchlist.UserID, "error", err)
+		}
+
+		if len(deferred) > 0 {
+			addWatchlistPending(watchlistRepo, watchlist, deferred)
+		}
+	}
+}
+
+func addWatchlistPending(watchlistRepo *db.WatchlistRepository, watchlist *structures.Watchlist, news []*structures.News) {
+	ids := make([]primitive.ObjectID, 0, len(news))
+	for _, n := range news {
+		ids = append(ids, n.Id)
+	}
+
+	if err := watchlistRepo.AddPending(watchlist.Id, ids); err != nil {
+		slog.Error("Failed to add news to digest", "platform", watchlist.Platform, "user_id", watchlist.UserID, "error", err)
+	}
+}
+
+func matchWatchlist(watchlist *structures.Watchlist, news []structures.News) []*structures.News {
+	var matched []*structures.News
+
+	for i := range news {
+		if news[i].Id.IsZero() {
+			continue
+		}
+		if watchlistMatches(watchlist, &news[i]) {
+			matched = append(matched, &news[i])
+		}
+	}
+
+	return matched
+}
+
+func watchlistMatches(watchlist *structures.Watchlist, news *structures.News) bool {
+	for _, provider := range watchlist.Providers {
+		if strings.EqualFold(provider, news.Provider) {
+			return true
+		}
+	}
+
+	text := strings.ToLower(news.Title + "\n" + news.Description)
+	for _, keyword := range watchlist.Keywords {
+		if strings.Contains(text, strings.ToLower(keyword)) {
+			return true
+		}
+	}
+
+	for _, expression := range watchlist.Regexes {
+		re, err := compileWatchRegex(expression)
+		if err != nil {
+			continue
+		}
+		if re.MatchString(news.Title) || re.MatchString(news.Description) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Выражения сравниваются без учета регистра, как и ключевые слова
+func compileWatchRegex(expression string) (*regexp.Regexp, error) {
+	watchRegexMu.Lock()
+	defer watchRegexMu.Unlock()
+
+	if re, ok := watchRegexCache[expression]; ok {
+		return re, nil
+	}
+
+	re, err := regexp.Compile("(?i)" + expression)
+	if err != nil {
+		return nil, err
+	}
+
+	watchRegexCache[expression] = re
+	return re, nil
+}
+
+func watchRuleCount(watchlist *structures.Watchlist) int {
+	if watchlist == nil {
+		return 0
+	}
+	return len(watchlist.Keywords) + len(watchlist.Regexes) + len(watchlist.Providers)
+}
+
+// Возвращает текст ответа пользователю; ошибка означает сбой базы, а не некорректный ввод
+func addWatchRule(platform, userID, kind, value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return "Укажите, что отслеживать", nil
+	}
+	if len([]rune(value)) > maxWatchRuleLength {
+		return fmt.Sprintf("Правило длиннее %d символов", maxWatchRuleLength), nil
+	}
+
+	switch kind {
+	case structures.WatchRuleRegex:
+		if _, err := compileWatchRegex(value); err != nil {
+			return fmt.Sprintf("Некорректное регулярное выражение: %v", err), nil
+		}
+	case structures.WatchRuleProvider:
+		provider, ok := FindProvider(value)
+		if !ok {
+			return "Источник не найден", nil
+		}
+		value = provider
+	case structures.WatchRuleKeyword:
+	default:
+		return "Неизвестный тип правила", nil
+	}
+
+	watchlistRepo := db.NewWatchlistRepository()
+
+	watchlist, err := watchlistRepo.FindByUser(platform, userID)
+	if err != nil {
+		return "", err
+	}
+
+	maxRules := pkg.Watchlists.MaxRules
+	if maxRules <= 0 {
+		maxRules = defaultWatchlistMaxRules
+	}
+	if watchRuleCount(watchlist) >= maxRules {
+		return fmt.Sprintf("В списке уже %d правил, удалите ненужные", maxRules), nil
+	}
+
+	if err := watchlistRepo.AddRule(platform, userID, kind, value); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("Добавлено в список отслеживания: %s", value), nil
+}
+
+func removeWatchRule(platform, userID, kind, value string) (string, error) {
+	value = strings.TrimSpace(value)
+	if kind == structures.WatchRuleProvider {
+		if provider, ok := FindProvider(value); ok {
+			value = provider
+		}
+	}
+
+	if err := db.NewWatchlistRepository().RemoveRule(platform, userID, kind, value); err != nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("Удалено из списка отслеживания: %s", value), nil
+}
+
+func setWatchDigest(platform, userID string, digest bool) (string, error) {
+	if err := db.NewWatchlistRepository().SetDigest(platform, userID, digest); err != nil {
+		return "", err
+	}
+
+	if digest {
+		return fmt.Sprintf("Совпадения будут приходить ежедневной сводкой в %s", watchlistDigestTime()), nil
+	}
+	return "Совпадения будут приходить сразу", nil
+}
+
+func clearWatchlist(platform, userID string) (string, error) {
+	if err := db.NewWatchlistRepository().Delete(platform, userID); err != nil {
+		return "", err
+	}
+	return "Список отслеживания очищен", nil
+}
+
+func describeWatchlist(platform, userID string) (string, error) {
+	watchlist, err := db.NewWatchlistRepository().FindByUser(platform, userID)
+	if err != nil {
+		return "", err
+	}
+
+	if watchRuleCount(watchlist) == 0 {
+		return "Список отслеживания пуст", nil
+	}
+
+	var result strings.Builder
+	writeRules := func(title string, values []string) {
+		if len(values) > 0 {
+			result.WriteString(fmt.Sprintf("%s: %s\n", title, strings.Join(values, ", ")))
+		}
+	}
+
+	writeRules("Ключевые слова", watchlist.Keywords)
+	writeRules("Регулярные выражения", watchlist.Regexes)
+	writeRules("Источники", watchlist.Providers)
+
+	if watchlist.Digest {
+		result.WriteString(fmt.Sprintf("Сводка: ежедневно в %s", watchlistDigestTime()))
+	} else {
+		result.WriteString("Сводка: выключена")
+	}
+
+	return result.String(), nil
+}
+
+func sendWatchNotification(watchlist *structures.Watchlist, news *structures.News) error {
+	switch watchlist.Platform {
+	case DestinationDiscord:
+		threadURLs := DiscordThreadURLs([]*structures.News{news})
+		return sendDiscordDirectMessage(watchlist.UserID, &discordgo.MessageSend{
+			Content: "Новость из вашего списка отслеживания",
+			Embeds:  []*discordgo.MessageEmbed{newsEmbed(news, threadURLs[news.Id])},
+		})
+	case DestinationTelegram:
+		return sendTelegramDirectMessage(watchlist.UserID, formatTelegramPost(news, telegramMessageLimit), telegramLinkPreview(news.URL))
+	}
+	return fmt.Errorf("неизвестная платформа %s", watchlist.Platform)
+}
+
+func sendWatchDigest(watchlist *structures.Watchlist, news []*structures.News) error {
+	switch watchlist.Platform {
+	case DestinationDiscord:
+		var content strings.Builder
+		content.WriteString("**Сводка по вашему списку отслеживания**\n\n")
+		for _, n := range news {
+			line := fmt.Sprintf("• [%s](<%s>) · %s\n", truncateText(n.Title, 150), n.URL, n.Provider)
+			if len([]rune(content.String()+line)) > 1990 {
+				content.WriteString("…")
+				break
+			}
+			content.WriteString(line)
+		}
+
+		return sendDiscordDirectMessage(watchlist.UserID, &discordgo.MessageSend{Content: content.String()})
+	case DestinationTelegram:
+		var text strings.Builder
+		text.WriteString("<b>Сводка по вашему списку отслеживания</b>\n\n")
+		for _, n := range news {
+			text.WriteString(fmt.Sprintf("• <a href=\"%s\">%s</a> · %s\n",
+				html.EscapeString(n.URL), html.EscapeString(n.Title), html.EscapeString(n.Provider)))
+		}
+
+		disabled := true
+		return sendTelegramDirectMessage(watchlist.UserID, truncateTelegramHTML(text.String()), &models.LinkPreviewOptions{IsDisabled: &disabled})
+	}
+	return fmt.Errorf("неизвестная платформа %s", watchlist.Platform)
+}
+
+func sendDiscordDirectMessage(userID string, message *discordgo.MessageSend) error {
+	if discordSession == nil {
+		return fmt.Errorf("discord бот не настроен")
+	}
+
+	channel, err := discordSession.UserChannelCreate(userID)
+	if err != nil {
+		return fmt.Errorf("ошибка при открытии личных сообщений: %w", err)
+	}
+
+	_, err = discordSession.ChannelMessageSendComplex(channel.ID, message)
+	return err
+}
+
+func sendTelegramDirectMessage(userID, text string, preview *models.LinkPreviewOptions) error {
+	if telegramBot == nil {
+		return fmt.Errorf("telegram бот не настроен")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	_, err := telegramBot.SendMessage(ctx, &bot.SendMessageParams{
+		ChatID:             userID,
+		Text:               text,
+		ParseMode:          models.ParseModeHTML,
+		LinkPreviewOptions: preview,
+	})
+	return err
+}
+
+func watchlistDigestTime() string {
+	if pkg.Watchlists.DigestTime == "" {
+		return defaultWatchlistDigest
+	}
+	return pkg.Watchlists.DigestTime
+}
+
+func nextDigestTime(from time.Time) (time.Time, error) {
+	clock, err := time.Parse("15:04", watchlistDigestTime())
+	if err != nil {
+		return time.Time{}, fmt.Errorf("некорректное время сводки %q: %w", watchlistDigestTime(), err)
+	}
+
+	next := time.Date(from.Year(), from.Month(), from.Day(), clock.Hour(), clock.Minute(), 0, 0, from.Location())
+	if !next.After(from) {
+		next = next.AddDate(0, 0, 1)
+	}
+
+	return next, nil
+}
+
+func startWatchlistDigest(ctx context.Context) {
+	if _, err := nextDigestTime(time.Now()); err != nil {
+		slog.Error("Daily digest disabled", "error", err)
+		return
+	}
+
+	goBackground(func() {
+		slog.Info("Watchlist daily digest scheduled", "time", watchlistDigestTime())
+
+		for {
+			next, _ := nextDigestTime(time.Now())
+			timer := time.NewTimer(time.Until(next))
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return
+			case <-timer.C:
+			}
+			sendWatchDigests()
+		}
+	})
+}
+
+func sendWatchDigests() {
+	watchlistMu.Lock()
+	defer watchlistMu.Unlock()
+
+	watchlistRepo := db.NewWatchlistRepository()
+	newsRepo := db.NewNewsRepository()
+
+	watchlists, err := watchlistRepo.FindWithPending()
+	if err != nil {
+		slog.Error("Failed to load digests", "error", err)
+		return
+	}
+
+	for _, watchlist := range watchlists {
+		news, err := newsRepo.FindByIDs(watchlist.Pending)
+		if err != nil {
+			slog.Error("Failed to load digest news", "platform", watchlist.Platform, "user_id", watchlist.UserID, "error", err)
+			continue
+		}
+
+		if len(news) > 0 {
+			if err := sendWatchDigest(watchlist, news); err != nil {
+				slog.Error("Failed to send digest", "platform", watchlist.Platform, "user_id", watchlist.UserID, "error", err)
+				continue
+			}
+		}
+
+		if err := watchlistRepo.ClearPending(watchlist.Id, watchlist.Pending); err != nil {
+			slog.Error("Failed to clear digest", "platform", watchlist.Platform, "user_id", watchlist.UserID, "error", err)
+		}
+	}
+}
@@ -0,0 +1,227 @@
+package services
+
+import (
+	"context"
+	"go-nelson/pkg"
+	"go-nelson/pkg/structures"
+	"html"
+	"log/slog"
+	"strconv"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/go-telegram/bot"
+	"github.com/go-telegram/bot/models"
+)
+
+const watchlistDisabledMessage = "Списки отслеживания отключены"
+
+func init() {
+	registerDiscordCommand(watchCommand(), handleWatchCommand)
+
+	registerTelegramCommand("watch", "Отслеживать: /watch <слово>, /watch regex <выражение>, /watch provider <источник>", handleTelegramWatch)
+	registerTelegramCommand("unwatch", "Перестать отслеживать, синтаксис как у /watch", handleTelegramUnwatch)
+	registerTelegramCommand("watchlist", "Показать список отслеживания", handleTelegramWatchlist)
+	registerTelegramCommand("digest", "Ежедневная сводка вместо мгновенных уведомлений: /digest on|off", handleTelegramDigest)
+}
+
+func watchCommand() *discordgo.ApplicationCommand {
+	ruleOptions := []*discordgo.ApplicationCommandOption{
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "type",
+			Description: "Тип правила",
+			Required:    true,
+			Choices: []*discordgo.ApplicationCommandOptionChoice{
+				{Name: "Ключевое слово", Value: structures.WatchRuleKeyword},
+				{Name: "Регулярное выражение", Value: structures.WatchRuleRegex},
+				{Name: "Источник", Value: structures.WatchRuleProvider},
+			},
+		},
+		{
+			Type:        discordgo.ApplicationCommandOptionString,
+			Name:        "value",
+			Description: "Что отслеживать",
+			Required:    true,
+			MaxLength:   maxWatchRuleLength,
+		},
+	}
+
+	return &discordgo.ApplicationCommand{
+		Name:        "watch",
+		Description: "Личный список отслеживания",
+		Options: []*discordgo.ApplicationCommandOption{
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "add",
+				Description: "Добавить правило",
+				Options:     ruleOptions,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "remove",
+				Description: "Удалить правило",
+				Options:     ruleOptions,
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "list",
+				Description: "Показать список отслеживания",
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "digest",
+				Description: "Ежедневная сводка вместо мгновенных уведомлений",
+				Options: []*discordgo.ApplicationCommandOption{
+					{
+						Type:        discordgo.ApplicationCommandOptionBoolean,
+						Name:        "enabled",
+						Description: "Включить сводку",
+						Required:    true,
+					},
+				},
+			},
+			{
+				Type:        discordgo.ApplicationCommandOptionSubCommand,
+				Name:        "clear",
+				Description: "Очистить список отслеживания",
+			},
+		},
+	}
+}
+
+func handleWatchCommand(s *discordgo.Session, i *discordgo.InteractionCreate) {
+	data := i.ApplicationCommandData()
+	if len(data.Options) == 0 || i.Type != discordgo.InteractionApplicationCommand {
+		return
+	}
+
+	subcommand := data.Options[0]
+	options := make(map[string]*discordgo.ApplicationCommandInteractionDataOption)
+	for _, option := range subcommand.Options {
+		options[option.Name] = option
+	}
+
+	userID := ""
+	if i.Member != nil && i.Member.User != nil {
+		userID = i.Member.User.ID
+	} else if i.User != nil {
+		userID = i.User.ID
+	}
+
+	var reply string
+	var err error
+	switch {
+	case !pkg.Watchlists.Enabled:
+		reply = watchlistDisabledMessage
+	case subcommand.Name == "add":
+		reply, err = addWatchRule(DestinationDiscord, userID, options["type"].StringValue(), options["value"].StringValue())
+	case subcommand.Name == "remove":
+		reply, err = removeWatchRule(DestinationDiscord, userID, options["type"].StringValue(), options["value"].StringValue())
+	case subcommand.Name == "list":
+		reply, err = describeWatchlist(DestinationDiscord, userID)
+	case subcommand.Name == "digest":
+		reply, err = setWatchDigest(DestinationDiscord, userID, options["enabled"].BoolValue())
+	case subcommand.Name == "clear":
+		reply, err = clearWatchlist(DestinationDiscord, userID)
+	default:
+		return
+	}
+
+	if err != nil {
+		slog.Error("Discord command failed", "command", "watch", "subcommand", subcommand.Name, "error", err)
+		reply = "Не удалось обновить список отслеживания, попробуйте позже"
+	}
+
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: reply,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		slog.Error("Failed to respond to Discord command", "command", "watch", "subcommand", subcommand.Name, "error", err)
+	}
+}
+
+// Первое слово может задавать тип правила: regex или provider, иначе весь текст считается ключевым словом
+func parseTelegramWatchRule(args string) (string, string) {
+	kind, value, found := strings.Cut(args, " ")
+	switch strings.ToLower(kind) {
+	case structures.WatchRuleRegex, structures.WatchRuleProvider:
+		if found {
+			return strings.ToLower(kind), strings.TrimSpace(value)
+		}
+	}
+	return structures.WatchRuleKeyword, args
+}
+
+func telegramUserID(message *models.Message) string {
+	if message == nil || message.From == nil {
+		return ""
+	}
+	return strconv.FormatInt(message.From.ID, 10)
+}
+
+func replyWatchlist(ctx context.Context, b *bot.Bot, message *models.Message, command string, reply string, err error) {
+	if err != nil {
+		slog.Error("Telegram command failed", "command", command, "error", err)
+		reply = "Не удалось обновить список отслеживания, попробуйте позже"
+	}
+
+	replyTelegram(ctx, b, message, html.EscapeString(reply))
+}
+
+func handleTelegramWatch(ctx context.Context, b *bot.Bot, message *models.Message, args string) {
+	if !pkg.Watchlists.Enabled {
+		replyTelegram(ctx, b, message, watchlistDisabledMessage)
+		return
+	}
+
+	kind, value := parseTelegramWatchRule(args)
+	reply, err := addWatchRule(DestinationTelegram, telegramUserID(message), kind, value)
+	replyWatchlist(ctx, b, message, "watch", reply, err)
+}
+
+func handleTelegramUnwatch(ctx context.Context, b *bot.Bot, message *models.Message, args string) {
+	if !pkg.Watchlists.Enabled {
+		replyTelegram(ctx, b, message, watchlistDisabledMessage)
+		return
+	}
+
+	kind, value := parseTelegramWatchRule(args)
+	reply, err := removeWatchRule(DestinationTelegram, telegramUserID(message), kind, value)
+	replyWatchlist(ctx, b, message, "unwatch", reply, err)
+}
+
+func handleTelegramWatchlist(ctx context.Context, b *bot.Bot, message *models.Message, _ string) {
+	if !pkg.Watchlists.Enabled {
+		replyTelegram(ctx, b, message, watchlistDisabledMessage)
+		return
+	}
+
+	reply, err := describeWatchlist(DestinationTelegram, telegramUserID(message))
+	replyWatchlist(ctx, b, message, "watchlist", reply, err)
+}
+
+func handleTelegramDigest(ctx context.Context, b *bot.Bot, message *models.Message, args string) {
+	if !pkg.Watchlists.Enabled {
+		replyTelegram(ctx, b, message, watchlistDisabledMessage)
+		return
+	}
+
+	var digest bool
+	switch strings.ToLower(args) {
+	case "on", "вкл":
+		digest = true
+	case "off", "выкл":
+		digest = false
+	default:
+		replyTelegram(ctx, b, message, "Использование: /digest on|off")
+		return
+	}
+
+	reply, err := setWatchDigest(DestinationTelegram, telegramUserID(message), digest)
+	replyWatchlist(ctx, b, message, "digest", reply, err)
+}
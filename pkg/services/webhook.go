@@ -0,0 +1,171 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"go-nelson/pkg/metrics"
+	"go-nelson/pkg/structures"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Версия схемы увеличивается только при несовместимых изменениях, новые поля добавляются без смены версии
+const webhookSchemaVersion = 1
+
+const (
+	webhookSignatureHeader = "X-Nelson-Signature"
+	webhookTimestampHeader = "X-Nelson-Timestamp"
+	webhookDeliveryHeader  = "X-Nelson-Delivery"
+	webhookEventHeader     = "X-Nelson-Event"
+	webhookVersionHeader   = "X-Nelson-Schema-Version"
+)
+
+const (
+	WebhookEventPublished = "news.published"
+	WebhookEventUpdated   = "news.updated"
+	WebhookEventDeleted   = "news.deleted"
+	WebhookEventRetracted = "news.retracted"
+)
+
+var webhookClient = &http.Client{Timeout: 30 * time.Second}
+
+type webhookPayload struct {
+	Version    int         `json:"version"`
+	Event      string      `json:"event"`
+	DeliveryID string      `json:"delivery_id"`
+	SentAt     time.Time   `json:"sent_at"`
+	News       webhookNews `json:"news"`
+}
+
+// Отдельная структура вместо structures.News, чтобы изменения в базе не ломали схему для получателей
+type webhookNews struct {
+	ID          string     `json:"id"`
+	Provider    string     `json:"provider"`
+	UniqueID    string     `json:"unique_id"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	URL         string     `json:"url"`
+	Tags        []string   `json:"tags"`
+	Images      []string   `json:"images"`
+	Language    string     `json:"language,omitempty"`
+	PublishedAt *time.Time `json:"published_at,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	RetractedAt *time.Time `json:"retracted_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+func StartWebhooks(ctx context.Context) {
+	handler := destinationHandler{
+		publish: webhookSender(WebhookEventPublished),
+		edit:    webhookSender(WebhookEventUpdated),
+		delete:  webhookSender(WebhookEventDeleted),
+		mark:    webhookSender(WebhookEventRetracted),
+	}
+
+	for _, target := range enabledTargets(DestinationWebhook) {
+		startOutboxWorker(ctx, target, handler)
+	}
+}
+
+// Повторы с нарастающей задержкой выполняет очередь доставки, поэтому здесь достаточно одной попытки
+func webhookSender(event string) deliverySender {
+	return func(ctx context.Context, target deliveryTarget, news *structures.News, delivery *structures.Delivery, messageID string) (string, error) {
+		body, err := json.Marshal(webhookPayload{
+			Version:    webhookSchemaVersion,
+			Event:      event,
+			DeliveryID: webhookDeliveryID(delivery),
+			SentAt:     time.Now().UTC(),
+			News:       makeWebhookNews(news),
+		})
+		if err != nil {
+			return messageID, fmt.Errorf("ошибка при формировании данных вебхука: %w", err)
+		}
+
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, target.WebhookURL, bytes.NewReader(body))
+		if err != nil {
+			return messageID, err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(webhookTimestampHeader, timestamp)
+		req.Header.Set(webhookDeliveryHeader, webhookDeliveryID(delivery))
+		req.Header.Set(webhookEventHeader, event)
+		req.Header.Set(webhookVersionHeader, strconv.Itoa(webhookSchemaVersion))
+		if target.Secret != "" {
+			req.Header.Set(webhookSignatureHeader, signWebhook(target.Secret, timestamp, body))
+		}
+
+		resp, err := webhookClient.Do(req)
+		if err != nil {
+			return messageID, fmt.Errorf("ошибка отправки вебхука: %w", err)
+		}
+		defer resp.Body.Close()
+
+		if resp.StatusCode == http.StatusTooManyRequests {
+			metrics.RateLimitHits.WithLabelValues(DestinationWebhook).Inc()
+		}
+
+		if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+			data, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+			return messageID, fmt.Errorf("вебхук вернул статус %s: %s", resp.Status, truncateText(string(data), 300))
+		}
+
+		slog.Info("News sent to webhook", "destination", target.Name, "news_id", news.Id.Hex(), "title", news.Title, "event", event)
+		return messageID, nil
+	}
+}
+
+// Одна запись очереди переиспользуется для правок и отзыва новости, поэтому ID события дополняется
+// действием и номером повторной постановки: он меняется между событиями, но не между повторами одного события
+func webhookDeliveryID(delivery *structures.Delivery) string {
+	action := delivery.Action
+	if action == "" {
+		action = structures.DeliveryActionPublish
+	}
+	return fmt.Sprintf("%s-%s-%d", delivery.Id.Hex(), action, delivery.Requeues)
+}
+
+// Подпись считается от "<timestamp>.<тело>", чтобы получатель мог отклонять повторно отправленные запросы
+func signWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+func makeWebhookNews(news *structures.News) webhookNews {
+	return webhookNews{
+		ID:          news.Id.Hex(),
+		Provider:    news.Provider,
+		UniqueID:    news.UniqueID,
+		Title:       news.Title,
+		Description: news.Description,
+		URL:         news.URL,
+		Tags:        append([]string{}, news.Tags...),
+		Images:      append([]string{}, news.Images...),
+		Language:    news.Language,
+		PublishedAt: optionalTime(news.PublishedAt),
+		ExpiresAt:   optionalTime(news.ExpiresAt),
+		RetractedAt: optionalTime(news.RetractedAt),
+		CreatedAt:   news.CreateAt,
+		UpdatedAt:   news.UpdateAt,
+	}
+}
+
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
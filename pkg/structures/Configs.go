@@ -1,10 +1,21 @@
 package structures
 
+import "encoding/json"
+
 type DiscordConfigStruct struct {
-	Token       string `json:"token"`
-	GuildID     string `json:"guild_id"`
-	Enabled     bool   `json:"enabled"`
-	NewsForumId string `json:"news_forum_id"`
+	Token       string                      `json:"token"`
+	GuildID     string                      `json:"guild_id"`
+	Enabled     bool                        `json:"enabled"`
+	NewsForumId string                      `json:"news_forum_id"`
+	Targets     []DiscordTargetConfigStruct `json:"targets"`
+}
+
+type DiscordTargetConfigStruct struct {
+	Name      string            `json:"name"`
+	GuildID   string            `json:"guild_id"`
+	ChannelID string            `json:"channel_id"`
+	Tags      map[string]string `json:"tags"`
+	Sources   []string          `json:"sources"`
 }
 
 type TelegramConfigStruct struct {
@@ -23,15 +34,117 @@ type GoogleAistudioConfigStruct struct {
 	APIKey string `json:"api_key"`
 }
 
-type ParsersConfigStruct struct {
-	ThreeDNews      bool `json:"3dnews"`
-	DisgustingMen   bool `json:"disgustingmen"`
-	DTF             bool `json:"dtf"`
-	EpicGames       bool `json:"epicgames"`
-	GamedevRu       bool `json:"gamedevru"`
-	Ixbt            bool `json:"ixbt"`
-	SteamDevelopers bool `json:"steam_developers"`
-	Stopgame        bool `json:"stopgame"`
+type ParsersConfigStruct map[string]SourceConfigStruct
+
+type SourceConfigStruct struct {
+	Enabled     bool                   `json:"enabled"`
+	Interval    string                 `json:"interval"`
+	Cron        string                 `json:"cron"`
+	Jitter      string                 `json:"jitter"`
+	HTTPProfile string                 `json:"http_profile"`
+	Language    string                 `json:"language"`
+	Retraction  RetractionConfigStruct `json:"retraction"`
+}
+
+type RetractionConfigStruct struct {
+	Policy      string `json:"policy"`
+	Window      string `json:"window"`
+	MissingRuns int    `json:"missing_runs"`
+}
+
+type RoutingConfigStruct struct {
+	Destinations map[string]DestinationConfigStruct `json:"destinations"`
+	Routes       []RouteConfigStruct                `json:"routes"`
+	Default      []string                           `json:"default"`
+}
+
+type DestinationConfigStruct struct {
+	Type        string            `json:"type"`
+	ChannelID   string            `json:"channel_id"`
+	WebhookURL  string            `json:"webhook_url"`
+	Forum       bool              `json:"forum"`
+	AppliedTags []string          `json:"applied_tags"`
+	Tags        map[string]string `json:"tags"`
+	Username    string            `json:"username"`
+	AvatarURL   string            `json:"avatar_url"`
+	Secret      string            `json:"secret"`
+}
+
+type RouteConfigStruct struct {
+	Name         string   `json:"name"`
+	Providers    []string `json:"providers"`
+	Tags         []string `json:"tags"`
+	Title        string   `json:"title"`
+	Description  string   `json:"description"`
+	Languages    []string `json:"languages"`
+	Destinations []string `json:"destinations"`
+}
+
+type WatchlistConfigStruct struct {
+	Enabled    bool   `json:"enabled"`
+	RateLimit  int    `json:"rate_limit"`
+	MaxRules   int    `json:"max_rules"`
+	DigestTime string `json:"digest_time"`
+}
+
+type LoggingConfigStruct struct {
+	Level    string `json:"level"`
+	Format   string `json:"format"`
+	Language string `json:"language"`
+}
+
+type ServerConfigStruct struct {
+	Enabled bool                   `json:"enabled"`
+	Address string                 `json:"address"`
+	BaseURL string                 `json:"base_url"`
+	Feed    FeedServerConfigStruct `json:"feed"`
+	API     APIConfigStruct        `json:"api"`
+	Admin   AdminConfigStruct      `json:"admin"`
+}
+
+type FeedServerConfigStruct struct {
+	Title    string `json:"title"`
+	Limit    int    `json:"limit"`
+	MaxLimit int    `json:"max_limit"`
+	MaxAge   string `json:"max_age"`
+}
+
+type AdminConfigStruct struct {
+	Password string `json:"password"`
+}
+
+type APIConfigStruct struct {
+	Keys []APIKeyConfigStruct `json:"keys"`
+}
+
+// RateLimit задается в запросах в минуту
+type APIKeyConfigStruct struct {
+	Name      string `json:"name"`
+	Key       string `json:"key"`
+	RateLimit int    `json:"rate_limit"`
+}
+
+type SchedulerConfigStruct struct {
+	DefaultInterval string `json:"default_interval"`
+	Workers         int    `json:"workers"`
+	RunTimeout      string `json:"run_timeout"`
+}
+
+func (s *SourceConfigStruct) UnmarshalJSON(data []byte) error {
+	var enabled bool
+	if err := json.Unmarshal(data, &enabled); err == nil {
+		*s = SourceConfigStruct{Enabled: enabled}
+		return nil
+	}
+
+	type plain SourceConfigStruct
+	var config plain
+	if err := json.Unmarshal(data, &config); err != nil {
+		return err
+	}
+
+	*s = SourceConfigStruct(config)
+	return nil
 }
 
 type ConfigStruct struct {
@@ -39,5 +152,106 @@ type ConfigStruct struct {
 	Telegram       TelegramConfigStruct       `json:"telegram"`
 	MongoDB        MongoDBConfigStruct        `json:"mongodb"`
 	GoogleAistudio GoogleAistudioConfigStruct `json:"google_aistudio"`
+	Delivery       DeliveryConfigStruct       `json:"delivery"`
+	HTTP           HTTPConfigStruct           `json:"http"`
+	Routing        RoutingConfigStruct        `json:"routing"`
+	Watchlists     WatchlistConfigStruct      `json:"watchlists"`
+	Server         ServerConfigStruct         `json:"server"`
+	Logging        LoggingConfigStruct        `json:"logging"`
+	Scheduler      SchedulerConfigStruct      `json:"scheduler"`
 	Parsers        ParsersConfigStruct        `json:"parsers"`
+	Feeds          []FeedConfigStruct         `json:"feeds"`
+	Scrapers       []ScraperConfigStruct      `json:"scrapers"`
+}
+
+type FeedConfigStruct struct {
+	Name           string                    `json:"name"`
+	Provider       string                    `json:"provider"`
+	Tag            string                    `json:"tag"`
+	URL            string                    `json:"url"`
+	Format         string                    `json:"format"`
+	UniqueID       string                    `json:"unique_id"`
+	UniqueIDPrefix string                    `json:"unique_id_prefix"`
+	UniqueIDPart   int                       `json:"unique_id_part"`
+	Image          []string                  `json:"image"`
+	DefaultImage   string                    `json:"default_image"`
+	Description    FeedDescriptionRuleStruct `json:"description"`
+	Tags           []string                  `json:"tags"`
+	CategoryTags   bool                      `json:"category_tags"`
+}
+
+type FeedDescriptionRuleStruct struct {
+	Source          string              `json:"source"`
+	Replace         []ReplaceRuleStruct `json:"replace"`
+	CutAt           string              `json:"cut_at"`
+	CutAtOccurrence int                 `json:"cut_at_occurrence"`
+	KeepHTML        bool                `json:"keep_html"`
+	SkipEmpty       bool                `json:"skip_empty"`
+}
+
+type ReplaceRuleStruct struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+type ScraperConfigStruct struct {
+	Name            string                    `json:"name"`
+	Provider        string                    `json:"provider"`
+	Tag             string                    `json:"tag"`
+	URL             string                    `json:"url"`
+	BaseURL         string                    `json:"base_url"`
+	ItemSelector    string                    `json:"item_selector"`
+	Title           SelectorRuleStruct        `json:"title"`
+	Link            SelectorRuleStruct        `json:"link"`
+	Description     SelectorRuleStruct        `json:"description"`
+	Image           SelectorRuleStruct        `json:"image"`
+	Date            SelectorRuleStruct        `json:"date"`
+	DateLayout      string                    `json:"date_layout"`
+	DescriptionRule FeedDescriptionRuleStruct `json:"description_rule"`
+	UniqueID        string                    `json:"unique_id"`
+	UniqueIDPrefix  string                    `json:"unique_id_prefix"`
+	UniqueIDPart    int                       `json:"unique_id_part"`
+	DefaultImage    string                    `json:"default_image"`
+	Tags            []string                  `json:"tags"`
+}
+
+type SelectorRuleStruct struct {
+	Selector string `json:"selector"`
+	Attr     string `json:"attr"`
+}
+
+type HTTPConfigStruct struct {
+	MaxBodySize   int64                        `json:"max_body_size"`
+	Retries       *int                         `json:"retries"`
+	RetryBackoff  string                       `json:"retry_backoff"`
+	MaxRetryDelay string                       `json:"max_retry_delay"`
+	Profiles      map[string]HTTPProfileStruct `json:"profiles"`
+}
+
+type HTTPProfileStruct struct {
+	UserAgent string               `json:"user_agent"`
+	Headers   map[string]string    `json:"headers"`
+	Cookies   []HTTPCookieStruct   `json:"cookies"`
+	Proxy     string               `json:"proxy"`
+	Timeout   string               `json:"timeout"`
+	TLS       HTTPProfileTLSStruct `json:"tls"`
+}
+
+type HTTPCookieStruct struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Domain string `json:"domain"`
+}
+
+type HTTPProfileTLSStruct struct {
+	InsecureSkipVerify bool   `json:"insecure_skip_verify"`
+	MinVersion         string `json:"min_version"`
+	ServerName         string `json:"server_name"`
+}
+
+type DeliveryConfigStruct struct {
+	MaxAttempts      int    `json:"max_attempts"`
+	PollInterval     string `json:"poll_interval"`
+	RetractionMarker string `json:"retraction_marker"`
+	ShutdownTimeout  string `json:"shutdown_timeout"`
 }
@@ -0,0 +1,45 @@
+package structures
+
+import (
+	"time"
+
+	"github.com/qiniu/qmgo/field"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	DeliveryStatusPending = "pending"
+	DeliveryStatusSent    = "sent"
+	DeliveryStatusFailed  = "failed"
+)
+
+const (
+	DeliveryActionPublish = "publish"
+	DeliveryActionEdit    = "edit"
+	DeliveryActionDelete  = "delete"
+	DeliveryActionMark    = "mark"
+)
+
+type Delivery struct {
+	field.DefaultField `bson:",inline"`
+	NewsID             primitive.ObjectID `bson:"news_id"`
+	Destination        string             `bson:"destination"`
+	Action             string             `bson:"action,omitempty"`
+	Status             string             `bson:"status"`
+	Attempts           int                `bson:"attempts"`
+	Requeues           int                `bson:"requeues,omitempty"`
+	NextRetryAt        time.Time          `bson:"next_retry_at"`
+	MessageID          string             `bson:"message_id,omitempty"`
+	PartMessageIDs     []string           `bson:"part_message_ids,omitempty"`
+	LastError          string             `bson:"last_error,omitempty"`
+	SentAt             time.Time          `bson:"sent_at,omitempty"`
+}
+
+type DeliveryQueueStats struct {
+	Key struct {
+		Destination string `bson:"destination"`
+		Status      string `bson:"status"`
+	} `bson:"_id"`
+	Count  int64     `bson:"count"`
+	Oldest time.Time `bson:"oldest"`
+}
@@ -0,0 +1,12 @@
+package structures
+
+import (
+	"github.com/qiniu/qmgo/field"
+)
+
+type HTTPCache struct {
+	field.DefaultField `bson:",inline"`
+	URL                string `bson:"url"`
+	ETag               string `bson:"etag,omitempty"`
+	LastModified       string `bson:"last_modified,omitempty"`
+}
@@ -1,19 +1,34 @@
 package structures
 
 import (
+	"time"
+
 	"github.com/qiniu/qmgo/field"
 )
 
 type News struct {
 	field.DefaultField `bson:",inline"`
-	Provider           string   `bson:"provider"`
-	UniqueID           string   `bson:"unique_id"`
-	Title              string   `bson:"title"`
-	Description        string   `bson:"description"`
-	URL                string   `bson:"url"`
-	Tags               []string `bson:"tags"`
-	Images             []string `bson:"images"`
-	TelegramMessageID  string   `bson:"telegram_message_id,omitempty"`
-	DiscordThreadID    string   `bson:"discord_thread_id,omitempty"`
-	DiscordMessageID   string   `bson:"discord_message_id,omitempty"`
+	Provider           string    `bson:"provider"`
+	UniqueID           string    `bson:"unique_id"`
+	Title              string    `bson:"title"`
+	Description        string    `bson:"description"`
+	URL                string    `bson:"url"`
+	Tags               []string  `bson:"tags"`
+	Images             []string  `bson:"images"`
+	Language           string    `bson:"language,omitempty"`
+	PublishedAt        time.Time `bson:"published_at,omitempty"`
+	ExpiresAt          time.Time `bson:"expires_at,omitempty"`
+	RetractedAt        time.Time `bson:"retracted_at,omitempty"`
+	MissingRuns        int       `bson:"missing_runs,omitempty"`
+	DeliveryPending    bool      `bson:"delivery_pending,omitempty"`
+	HiddenAt           time.Time `bson:"hidden_at,omitempty"`
+	TelegramMessageID  string    `bson:"telegram_message_id,omitempty"`
+	DiscordThreadID    string    `bson:"discord_thread_id,omitempty"`
+	DiscordMessageID   string    `bson:"discord_message_id,omitempty"`
+}
+
+type ProviderStats struct {
+	Provider string    `bson:"_id"`
+	Count    int64     `bson:"count"`
+	LastAt   time.Time `bson:"last_at"`
 }
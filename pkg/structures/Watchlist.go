@@ -0,0 +1,28 @@
+package structures
+
+import (
+	"time"
+
+	"github.com/qiniu/qmgo/field"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	WatchRuleKeyword  = "keyword"
+	WatchRuleRegex    = "regex"
+	WatchRuleProvider = "provider"
+)
+
+type Watchlist struct {
+	field.DefaultField `bson:",inline"`
+	Platform           string               `bson:"platform"`
+	UserID             string               `bson:"user_id"`
+	Keywords           []string             `bson:"keywords,omitempty"`
+	Regexes            []string             `bson:"regexes,omitempty"`
+	Providers          []string             `bson:"providers,omitempty"`
+	Digest             bool                 `bson:"digest"`
+	Pending            []primitive.ObjectID `bson:"pending,omitempty"`
+	WindowStart        time.Time            `bson:"window_start,omitempty"`
+	WindowCount        int                  `bson:"window_count"`
+	LastDigestAt       time.Time            `bson:"last_digest_at,omitempty"`
+}
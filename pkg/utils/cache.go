@@ -0,0 +1,100 @@
+package utils
+
+import (
+	"context"
+	"errors"
+	"go-nelson/pkg/structures"
+	"log/slog"
+	"sync"
+)
+
+var ErrNotModified = errors.New("ресурс не изменился")
+
+type ValidatorStore interface {
+	FindByURL(url string) (*structures.HTTPCache, error)
+	Save(cache *structures.HTTPCache) error
+}
+
+var (
+	validatorStoreMu sync.RWMutex
+	validatorStore   ValidatorStore
+)
+
+func SetValidatorStore(store ValidatorStore) {
+	validatorStoreMu.Lock()
+	defer validatorStoreMu.Unlock()
+
+	validatorStore = store
+}
+
+func getValidatorStore() ValidatorStore {
+	validatorStoreMu.RLock()
+	defer validatorStoreMu.RUnlock()
+
+	return validatorStore
+}
+
+type pendingValidatorsKey struct{}
+
+type pendingValidators struct {
+	mu    sync.Mutex
+	items map[string]*structures.HTTPCache
+}
+
+// WithPendingValidators откладывает сохранение ETag/Last-Modified до вызова commit,
+// чтобы ответ, который не удалось обработать, не превратился в 304 при следующем запросе.
+func WithPendingValidators(ctx context.Context) (context.Context, func()) {
+	pending := &pendingValidators{items: make(map[string]*structures.HTTPCache)}
+
+	commit := func() {
+		store := getValidatorStore()
+		if store == nil {
+			return
+		}
+
+		pending.mu.Lock()
+		defer pending.mu.Unlock()
+
+		for url, cache := range pending.items {
+			if err := store.Save(cache); err != nil {
+				slog.Error("Failed to save HTTP cache", "url", url, "error", err)
+			}
+		}
+		pending.items = make(map[string]*structures.HTTPCache)
+	}
+
+	return context.WithValue(ctx, pendingValidatorsKey{}, pending), commit
+}
+
+func loadValidators(url string) *structures.HTTPCache {
+	store := getValidatorStore()
+	if store == nil {
+		return nil
+	}
+
+	cache, err := store.FindByURL(url)
+	if err != nil {
+		slog.Error("Failed to read HTTP cache", "url", url, "error", err)
+		return nil
+	}
+
+	return cache
+}
+
+func storeValidators(ctx context.Context, cache *structures.HTTPCache) {
+	if pending, ok := ctx.Value(pendingValidatorsKey{}).(*pendingValidators); ok {
+		pending.mu.Lock()
+		pending.items[cache.URL] = cache
+		pending.mu.Unlock()
+		return
+	}
+
+	store := getValidatorStore()
+	if store == nil {
+		return
+	}
+
+	if err := store.Save(cache); err != nil {
+		slog.Error("Failed to save HTTP cache", "url", cache.URL, "error", err)
+	}
+}
@@ -1,57 +1,395 @@
 package utils
 
 import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"errors"
+	"fmt"
+	"go-nelson/pkg/metrics"
+	"go-nelson/pkg/structures"
 	"io"
+	"log/slog"
+	"net"
 	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
 	"time"
+
+	"github.com/andybalholm/brotli"
 )
 
-type Fetcher struct {
-	client *http.Client
+const (
+	defaultMaxBodySize   = 32 * 1024 * 1024
+	defaultRetries       = 3
+	defaultRetryBackoff  = 1 * time.Second
+	defaultMaxRetryDelay = 30 * time.Second
+)
+
+type HTTPStatusError struct {
+	URL        string
+	StatusCode int
+	Status     string
+	RetryAfter time.Duration
 }
 
-func NewFetcher() *Fetcher {
-	client := &http.Client{
-		Timeout: 30 * time.Second,
+func (e *HTTPStatusError) Error() string {
+	return fmt.Sprintf("сервер вернул статус %s для %s", e.Status, e.URL)
+}
+
+func (e *HTTPStatusError) Temporary() bool {
+	switch e.StatusCode {
+	case http.StatusRequestTimeout, http.StatusTooManyRequests, http.StatusInternalServerError,
+		http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+type BodyTooLargeError struct {
+	URL   string
+	Limit int64
+}
+
+func (e *BodyTooLargeError) Error() string {
+	return fmt.Sprintf("размер ответа %s превышает лимит %d байт", e.URL, e.Limit)
+}
+
+type fetcherSettings struct {
+	maxBodySize   int64
+	retries       int
+	retryBackoff  time.Duration
+	maxRetryDelay time.Duration
+}
+
+var (
+	fetcherSettingsMu sync.RWMutex
+	defaultSettings   = fetcherSettings{
+		maxBodySize:   defaultMaxBodySize,
+		retries:       defaultRetries,
+		retryBackoff:  defaultRetryBackoff,
+		maxRetryDelay: defaultMaxRetryDelay,
+	}
+)
+
+func ConfigureFetcher(config structures.HTTPConfigStruct) error {
+	settings := fetcherSettings{
+		maxBodySize:   defaultMaxBodySize,
+		retries:       defaultRetries,
+		retryBackoff:  defaultRetryBackoff,
+		maxRetryDelay: defaultMaxRetryDelay,
+	}
+
+	if config.MaxBodySize > 0 {
+		settings.maxBodySize = config.MaxBodySize
+	}
+
+	if config.Retries != nil {
+		if *config.Retries < 0 {
+			return fmt.Errorf("количество повторов не может быть отрицательным")
+		}
+		settings.retries = *config.Retries
+	}
+
+	if config.RetryBackoff != "" {
+		backoff, err := time.ParseDuration(config.RetryBackoff)
+		if err != nil {
+			return fmt.Errorf("некорректная задержка повтора '%s': %v", config.RetryBackoff, err)
+		}
+		settings.retryBackoff = backoff
+	}
+
+	if config.MaxRetryDelay != "" {
+		maxDelay, err := time.ParseDuration(config.MaxRetryDelay)
+		if err != nil {
+			return fmt.Errorf("некорректная максимальная задержка повтора '%s': %v", config.MaxRetryDelay, err)
+		}
+		settings.maxRetryDelay = maxDelay
 	}
 
+	if err := configureHTTPProfiles(config.Profiles); err != nil {
+		return err
+	}
+
+	fetcherSettingsMu.Lock()
+	defaultSettings = settings
+	fetcherSettingsMu.Unlock()
+
+	return nil
+}
+
+func currentFetcherSettings() fetcherSettings {
+	fetcherSettingsMu.RLock()
+	defer fetcherSettingsMu.RUnlock()
+
+	return defaultSettings
+}
+
+type Fetcher struct {
+	profile     string
+	conditional bool
+	settings    fetcherSettings
+}
+
+func NewFetcher() *Fetcher {
 	return &Fetcher{
-		client: client,
+		profile:  DefaultHTTPProfile,
+		settings: currentFetcherSettings(),
 	}
 }
 
+func NewConditionalFetcher() *Fetcher {
+	fetcher := NewFetcher()
+	fetcher.conditional = true
+	return fetcher
+}
+
 func (f *Fetcher) Fetch(url string) ([]byte, error) {
-	req, err := http.NewRequest("GET", url, nil)
+	return f.FetchContext(context.Background(), url)
+}
+
+func (f *Fetcher) FetchContext(ctx context.Context, url string) ([]byte, error) {
+	profileName := f.profile
+	if name, ok := httpProfileFromContext(ctx); ok {
+		profileName = name
+	}
+	profile := getHTTPProfile(profileName)
+
+	var cached *structures.HTTPCache
+	if f.conditional {
+		cached = loadValidators(url)
+	}
+
+	var lastErr error
+	for attempt := 0; attempt <= f.settings.retries; attempt++ {
+		if attempt > 0 {
+			delay, ok := f.retryDelay(attempt, lastErr)
+			if !ok {
+				slog.Warn("Retry-After exceeds max retry delay, giving up", "url", url, "retry_after", delay, "max_retry_delay", f.settings.maxRetryDelay)
+				return nil, lastErr
+			}
+			slog.Info("Retrying request", "url", url, "delay", delay, "attempt", attempt, "max_attempts", f.settings.retries, "error", lastErr)
+
+			timer := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return nil, fmt.Errorf("%w (последняя ошибка: %v)", ctx.Err(), lastErr)
+			case <-timer.C:
+			}
+		}
+
+		body, err := f.fetchOnce(ctx, profile, url, cached)
+		if err == nil {
+			return body, nil
+		}
+
+		if !isRetryable(ctx, err) {
+			return nil, err
+		}
+		lastErr = err
+	}
+
+	return nil, lastErr
+}
+
+func (f *Fetcher) fetchOnce(ctx context.Context, profile *httpProfile, url string, cached *structures.HTTPCache) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return nil, err
 	}
 
-	req.Header.Set("User-Agent", "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36")
-	req.Header.Set("Accept", "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,image/apng,*/*;q=0.8,application/signed-exchange;v=b3;q=0.7")
-	req.Header.Set("Accept-Language", "ru-RU,ru;q=0.9,en-US;q=0.8,en;q=0.7")
-	req.Header.Set("Cache-Control", "max-age=0")
-	req.Header.Set("Sec-Ch-Ua", "\"Not A(Brand\";v=\"99\", \"Google Chrome\";v=\"120\", \"Chromium\";v=\"120\"")
-	req.Header.Set("Sec-Ch-Ua-Mobile", "?0")
-	req.Header.Set("Sec-Ch-Ua-Platform", "\"Windows\"")
+	profile.apply(req)
+	req.Header.Set("Accept-Encoding", "gzip, deflate, br")
 
-	steamLangCookie := &http.Cookie{
-		Name:   "Steam_Language",
-		Value:  "russian",
-		Path:   "/",
-		Domain: ".steampowered.com",
+	if cached != nil {
+		if cached.ETag != "" {
+			req.Header.Set("If-None-Match", cached.ETag)
+		}
+		if cached.LastModified != "" {
+			req.Header.Set("If-Modified-Since", cached.LastModified)
+		}
+		req.Header.Del("Cache-Control")
 	}
-	req.AddCookie(steamLangCookie)
 
-	resp, err := f.client.Do(req)
+	resp, err := profile.client.Do(req)
 	if err != nil {
 		return nil, err
 	}
 	defer resp.Body.Close()
 
-	body, err := io.ReadAll(resp.Body)
+	metrics.RecordHTTPResponse(ctx, resp.StatusCode)
+
+	if resp.StatusCode == http.StatusNotModified && cached != nil {
+		return nil, ErrNotModified
+	}
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+		return nil, &HTTPStatusError{
+			URL:        url,
+			StatusCode: resp.StatusCode,
+			Status:     resp.Status,
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+		}
+	}
+
+	body, err := f.readBody(url, resp)
 	if err != nil {
 		return nil, err
 	}
 
+	if f.conditional {
+		etag := resp.Header.Get("ETag")
+		lastModified := resp.Header.Get("Last-Modified")
+		if etag != "" || lastModified != "" {
+			storeValidators(ctx, &structures.HTTPCache{
+				URL:          url,
+				ETag:         etag,
+				LastModified: lastModified,
+			})
+		}
+	}
+
 	return body, nil
 }
+
+func (f *Fetcher) readBody(url string, resp *http.Response) ([]byte, error) {
+	if f.settings.maxBodySize > 0 && resp.ContentLength > f.settings.maxBodySize {
+		return nil, &BodyTooLargeError{URL: url, Limit: f.settings.maxBodySize}
+	}
+
+	reader, err := f.decodeBody(url, resp)
+	if err != nil {
+		var sizeErr *BodyTooLargeError
+		if errors.As(err, &sizeErr) {
+			return nil, err
+		}
+		return nil, fmt.Errorf("ошибка при распаковке ответа %s: %w", url, err)
+	}
+	defer reader.Close()
+
+	if f.settings.maxBodySize <= 0 {
+		return io.ReadAll(reader)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(reader, f.settings.maxBodySize+1))
+	if err != nil {
+		return nil, err
+	}
+
+	if int64(len(body)) > f.settings.maxBodySize {
+		return nil, &BodyTooLargeError{URL: url, Limit: f.settings.maxBodySize}
+	}
+
+	return body, nil
+}
+
+func (f *Fetcher) decodeBody(url string, resp *http.Response) (io.ReadCloser, error) {
+	encoding := strings.ToLower(strings.TrimSpace(resp.Header.Get("Content-Encoding")))
+
+	switch encoding {
+	case "", "identity":
+		return io.NopCloser(resp.Body), nil
+	case "gzip", "x-gzip":
+		return gzip.NewReader(resp.Body)
+	case "deflate":
+		// Большинство серверов отдают deflate в обертке zlib, но встречается и "сырой" поток.
+		// Для определения формата сжатое тело читается целиком, поэтому лимит применяется и к нему
+		var body io.Reader = resp.Body
+		if f.settings.maxBodySize > 0 {
+			body = io.LimitReader(resp.Body, f.settings.maxBodySize+1)
+		}
+		compressed, err := io.ReadAll(body)
+		if err != nil {
+			return nil, err
+		}
+		if f.settings.maxBodySize > 0 && int64(len(compressed)) > f.settings.maxBodySize {
+			return nil, &BodyTooLargeError{URL: url, Limit: f.settings.maxBodySize}
+		}
+		if reader, err := zlib.NewReader(bytes.NewReader(compressed)); err == nil {
+			return reader, nil
+		}
+		return flate.NewReader(bytes.NewReader(compressed)), nil
+	case "br":
+		return io.NopCloser(brotli.NewReader(resp.Body)), nil
+	default:
+		return nil, fmt.Errorf("неподдерживаемое сжатие: %s", encoding)
+	}
+}
+
+// Retry-After сервера не сокращается: если он больше max_retry_delay, повтор не выполняется
+// и возвращается false, чтобы не обращаться к серверу раньше запрошенного
+func (f *Fetcher) retryDelay(attempt int, lastErr error) (time.Duration, bool) {
+	var statusErr *HTTPStatusError
+	if errors.As(lastErr, &statusErr) && statusErr.RetryAfter > 0 {
+		if f.settings.maxRetryDelay > 0 && statusErr.RetryAfter > f.settings.maxRetryDelay {
+			return statusErr.RetryAfter, false
+		}
+		return statusErr.RetryAfter, true
+	}
+
+	delay := f.settings.retryBackoff << (attempt - 1)
+	delay += RandomDuration(f.settings.retryBackoff)
+
+	if f.settings.maxRetryDelay > 0 && delay > f.settings.maxRetryDelay {
+		delay = f.settings.maxRetryDelay
+	}
+
+	return delay, true
+}
+
+func isRetryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	if errors.Is(err, ErrNotModified) {
+		return false
+	}
+
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Temporary()
+	}
+
+	var sizeErr *BodyTooLargeError
+	if errors.As(err, &sizeErr) {
+		return false
+	}
+
+	// Любая ошибка запроса оборачивается в *url.Error, который сам реализует net.Error,
+	// поэтому повторяются только таймауты и обрывы соединения, а ошибки TLS, DNS и схемы постоянны
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return true
+	}
+
+	return errors.Is(err, syscall.ECONNRESET) ||
+		errors.Is(err, syscall.ECONNREFUSED) ||
+		errors.Is(err, io.ErrUnexpectedEOF)
+}
+
+func parseRetryAfter(value string) time.Duration {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		if delay := time.Until(date); delay > 0 {
+			return delay
+		}
+	}
+
+	return 0
+}
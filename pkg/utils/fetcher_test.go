@@ -0,0 +1,200 @@
+package utils
+
+import (
+	"bytes"
+	"compress/flate"
+	"compress/gzip"
+	"compress/zlib"
+	"context"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/andybalholm/brotli"
+)
+
+func TestRetryDelay(t *testing.T) {
+	fetcher := &Fetcher{settings: fetcherSettings{
+		retryBackoff:  time.Second,
+		maxRetryDelay: 10 * time.Second,
+	}}
+
+	tests := []struct {
+		name    string
+		attempt int
+		err     error
+		min     time.Duration
+		max     time.Duration
+		retry   bool
+	}{
+		{name: "первый повтор", attempt: 1, err: io.ErrUnexpectedEOF, min: time.Second, max: 2 * time.Second, retry: true},
+		{name: "задержка удваивается", attempt: 3, err: io.ErrUnexpectedEOF, min: 4 * time.Second, max: 5 * time.Second, retry: true},
+		{name: "задержка ограничена max_retry_delay", attempt: 6, err: io.ErrUnexpectedEOF, min: 10 * time.Second, max: 10 * time.Second, retry: true},
+		{
+			name:    "Retry-After сервера",
+			attempt: 1,
+			err:     &HTTPStatusError{StatusCode: http.StatusTooManyRequests, RetryAfter: 7 * time.Second},
+			min:     7 * time.Second,
+			max:     7 * time.Second,
+			retry:   true,
+		},
+		{
+			name:    "Retry-After больше max_retry_delay не сокращается",
+			attempt: 1,
+			err:     fmt.Errorf("запрос: %w", &HTTPStatusError{StatusCode: http.StatusServiceUnavailable, RetryAfter: time.Minute}),
+			min:     time.Minute,
+			max:     time.Minute,
+			retry:   false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			delay, retry := fetcher.retryDelay(tt.attempt, tt.err)
+			if retry != tt.retry {
+				t.Errorf("retryDelay() retry = %v, want %v", retry, tt.retry)
+			}
+			if delay < tt.min || delay > tt.max {
+				t.Errorf("retryDelay() delay = %s, want between %s and %s", delay, tt.min, tt.max)
+			}
+		})
+	}
+}
+
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func TestIsRetryable(t *testing.T) {
+	requestErr := func(err error) error {
+		return &url.Error{Op: "Get", URL: "https://example.com", Err: err}
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{name: "таймаут", err: requestErr(timeoutError{}), want: true},
+		{name: "сброс соединения", err: requestErr(&net.OpError{Op: "read", Err: os.NewSyscallError("read", syscall.ECONNRESET)}), want: true},
+		{name: "отказ в соединении", err: requestErr(&net.OpError{Op: "dial", Err: os.NewSyscallError("connect", syscall.ECONNREFUSED)}), want: true},
+		{name: "оборванный ответ", err: fmt.Errorf("чтение: %w", io.ErrUnexpectedEOF), want: true},
+		{name: "ошибка DNS", err: requestErr(&net.OpError{Op: "dial", Err: &net.DNSError{Err: "no such host", Name: "example.invalid", IsNotFound: true}}), want: false},
+		{name: "ошибка сертификата", err: requestErr(x509.UnknownAuthorityError{}), want: false},
+		{name: "неподдерживаемая схема", err: requestErr(errors.New("unsupported protocol scheme")), want: false},
+		{name: "503", err: &HTTPStatusError{StatusCode: http.StatusServiceUnavailable}, want: true},
+		{name: "429", err: &HTTPStatusError{StatusCode: http.StatusTooManyRequests}, want: true},
+		{name: "404", err: &HTTPStatusError{StatusCode: http.StatusNotFound}, want: false},
+		{name: "ответ не изменился", err: ErrNotModified, want: false},
+		{name: "превышен размер ответа", err: &BodyTooLargeError{URL: "https://example.com", Limit: 1}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isRetryable(context.Background(), tt.err); got != tt.want {
+				t.Errorf("isRetryable() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	t.Run("отмененный контекст", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+
+		if isRetryable(ctx, requestErr(timeoutError{})) {
+			t.Error("isRetryable() = true, want false")
+		}
+	})
+}
+
+func TestDecodeBody(t *testing.T) {
+	const text = "Тело ответа"
+
+	compress := func(newWriter func(io.Writer) io.WriteCloser) []byte {
+		var buf bytes.Buffer
+		writer := newWriter(&buf)
+		writer.Write([]byte(text))
+		writer.Close()
+		return buf.Bytes()
+	}
+
+	tests := []struct {
+		name     string
+		encoding string
+		body     []byte
+	}{
+		{name: "без сжатия", encoding: "", body: []byte(text)},
+		{name: "identity", encoding: "identity", body: []byte(text)},
+		{name: "gzip", encoding: "gzip", body: compress(func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) })},
+		{name: "x-gzip в верхнем регистре", encoding: " X-GZIP ", body: compress(func(w io.Writer) io.WriteCloser { return gzip.NewWriter(w) })},
+		{name: "deflate в обертке zlib", encoding: "deflate", body: compress(func(w io.Writer) io.WriteCloser { return zlib.NewWriter(w) })},
+		{name: "сырой deflate", encoding: "deflate", body: compress(func(w io.Writer) io.WriteCloser {
+			writer, _ := flate.NewWriter(w, flate.DefaultCompression)
+			return writer
+		})},
+		{name: "brotli", encoding: "br", body: compress(func(w io.Writer) io.WriteCloser { return brotli.NewWriter(w) })},
+	}
+
+	fetcher := &Fetcher{settings: fetcherSettings{maxBodySize: 1024}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{
+				Header: http.Header{"Content-Encoding": []string{tt.encoding}},
+				Body:   io.NopCloser(bytes.NewReader(tt.body)),
+			}
+
+			reader, err := fetcher.decodeBody("https://example.com", resp)
+			if err != nil {
+				t.Fatalf("decodeBody() error = %v", err)
+			}
+			defer reader.Close()
+
+			got, err := io.ReadAll(reader)
+			if err != nil {
+				t.Fatalf("чтение распакованного тела: %v", err)
+			}
+			if string(got) != text {
+				t.Errorf("decodeBody() = %q, want %q", got, text)
+			}
+		})
+	}
+}
+
+func TestDecodeBodyErrors(t *testing.T) {
+	t.Run("неподдерживаемое сжатие", func(t *testing.T) {
+		fetcher := &Fetcher{}
+		resp := &http.Response{
+			Header: http.Header{"Content-Encoding": []string{"zstd"}},
+			Body:   io.NopCloser(bytes.NewReader(nil)),
+		}
+
+		if _, err := fetcher.decodeBody("https://example.com", resp); err == nil {
+			t.Error("decodeBody() error = nil, want error")
+		}
+	})
+
+	t.Run("сжатое тело deflate больше лимита", func(t *testing.T) {
+		fetcher := &Fetcher{settings: fetcherSettings{maxBodySize: 8}}
+		resp := &http.Response{
+			Header: http.Header{"Content-Encoding": []string{"deflate"}},
+			Body:   io.NopCloser(bytes.NewReader(bytes.Repeat([]byte{0}, 64))),
+		}
+
+		_, err := fetcher.decodeBody("https://example.com", resp)
+		var sizeErr *BodyTooLargeError
+		if !errors.As(err, &sizeErr) {
+			t.Errorf("decodeBody() error = %v, want *BodyTooLargeError", err)
+		}
+	})
+}
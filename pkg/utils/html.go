@@ -120,6 +120,8 @@ func ParseRSSDate(dateStr string) (time.Time, error) {
 		"Mon, 02 Jan 2006 15:04:05 -0700",
 		"Mon, 02 Jan 2006 15:04:05 +0300",
 		"Mon, 02 Jan 2006 15:04:05 MST",
+		time.RFC3339,
+		time.RFC3339Nano,
 	}
 
 	for _, layout := range layouts {
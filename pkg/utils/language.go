@@ -0,0 +1,26 @@
+package utils
+
+import "unicode"
+
+// Язык определяется по преобладающей письменности: кириллица считается русским, латиница английским
+func DetectLanguage(text string) string {
+	var cyrillic, latin int
+
+	for _, r := range text {
+		switch {
+		case unicode.Is(unicode.Cyrillic, r):
+			cyrillic++
+		case unicode.Is(unicode.Latin, r):
+			latin++
+		}
+	}
+
+	switch {
+	case cyrillic == 0 && latin == 0:
+		return ""
+	case cyrillic >= latin:
+		return "ru"
+	default:
+		return "en"
+	}
+}
@@ -0,0 +1,221 @@
+package utils
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"go-nelson/pkg/structures"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+const DefaultHTTPProfile = "default"
+
+const defaultHTTPTimeout = 30 * time.Second
+
+var baseHeaders = map[string]string{
+	"User-Agent":         "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+	"Accept":             "text/html,application/xhtml+xml,application/xml;q=0.9,image/avif,image/webp,image/apng,*/*;q=0.8,application/signed-exchange;v=b3;q=0.7",
+	"Accept-Language":    "ru-RU,ru;q=0.9,en-US;q=0.8,en;q=0.7",
+	"Cache-Control":      "max-age=0",
+	"Sec-Ch-Ua":          "\"Not A(Brand\";v=\"99\", \"Google Chrome\";v=\"120\", \"Chromium\";v=\"120\"",
+	"Sec-Ch-Ua-Mobile":   "?0",
+	"Sec-Ch-Ua-Platform": "\"Windows\"",
+}
+
+var builtinDefaultProfile = structures.HTTPProfileStruct{
+	Cookies: []structures.HTTPCookieStruct{
+		{
+			Name:   "Steam_Language",
+			Value:  "russian",
+			Domain: ".steampowered.com",
+		},
+	},
+}
+
+type httpProfile struct {
+	name    string
+	client  *http.Client
+	headers map[string]string
+	cookies []structures.HTTPCookieStruct
+}
+
+var (
+	httpProfilesMu sync.RWMutex
+	httpProfiles   = map[string]*httpProfile{}
+)
+
+func init() {
+	profile, err := newHTTPProfile(DefaultHTTPProfile, builtinDefaultProfile)
+	if err != nil {
+		panic(err)
+	}
+	httpProfiles[DefaultHTTPProfile] = profile
+}
+
+func configureHTTPProfiles(configs map[string]structures.HTTPProfileStruct) error {
+	profiles := make(map[string]*httpProfile)
+
+	if _, ok := configs[DefaultHTTPProfile]; !ok {
+		profile, err := newHTTPProfile(DefaultHTTPProfile, builtinDefaultProfile)
+		if err != nil {
+			return err
+		}
+		profiles[DefaultHTTPProfile] = profile
+	}
+
+	for name, config := range configs {
+		profile, err := newHTTPProfile(name, config)
+		if err != nil {
+			return fmt.Errorf("ошибка в HTTP-профиле %s: %w", name, err)
+		}
+		profiles[name] = profile
+	}
+
+	httpProfilesMu.Lock()
+	httpProfiles = profiles
+	httpProfilesMu.Unlock()
+
+	return nil
+}
+
+func HasHTTPProfile(name string) bool {
+	httpProfilesMu.RLock()
+	defer httpProfilesMu.RUnlock()
+
+	_, ok := httpProfiles[name]
+	return ok
+}
+
+func getHTTPProfile(name string) *httpProfile {
+	if name == "" {
+		name = DefaultHTTPProfile
+	}
+
+	httpProfilesMu.RLock()
+	defer httpProfilesMu.RUnlock()
+
+	if profile, ok := httpProfiles[name]; ok {
+		return profile
+	}
+
+	slog.Warn("HTTP profile not found, using default", "profile", name)
+	return httpProfiles[DefaultHTTPProfile]
+}
+
+func newHTTPProfile(name string, config structures.HTTPProfileStruct) (*httpProfile, error) {
+	timeout := defaultHTTPTimeout
+	if config.Timeout != "" {
+		parsed, err := time.ParseDuration(config.Timeout)
+		if err != nil {
+			return nil, fmt.Errorf("некорректный таймаут '%s': %v", config.Timeout, err)
+		}
+		timeout = parsed
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+
+	if config.Proxy != "" {
+		proxyURL, err := url.Parse(config.Proxy)
+		if err != nil {
+			return nil, fmt.Errorf("некорректный адрес прокси: %v", err)
+		}
+
+		switch proxyURL.Scheme {
+		case "http", "https", "socks5", "socks5h":
+		default:
+			return nil, fmt.Errorf("неподдерживаемая схема прокси: %s", proxyURL.Scheme)
+		}
+
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+
+	tlsConfig := &tls.Config{
+		InsecureSkipVerify: config.TLS.InsecureSkipVerify,
+		ServerName:         config.TLS.ServerName,
+	}
+
+	switch config.TLS.MinVersion {
+	case "":
+	case "1.0":
+		tlsConfig.MinVersion = tls.VersionTLS10
+	case "1.1":
+		tlsConfig.MinVersion = tls.VersionTLS11
+	case "1.2":
+		tlsConfig.MinVersion = tls.VersionTLS12
+	case "1.3":
+		tlsConfig.MinVersion = tls.VersionTLS13
+	default:
+		return nil, fmt.Errorf("неизвестная версия TLS: %s", config.TLS.MinVersion)
+	}
+	transport.TLSClientConfig = tlsConfig
+
+	headers := make(map[string]string, len(baseHeaders)+len(config.Headers))
+	for key, value := range baseHeaders {
+		headers[key] = value
+	}
+	for key, value := range config.Headers {
+		headers[http.CanonicalHeaderKey(key)] = value
+	}
+	if config.UserAgent != "" {
+		headers["User-Agent"] = config.UserAgent
+	}
+
+	return &httpProfile{
+		name: name,
+		client: &http.Client{
+			Timeout:   timeout,
+			Transport: transport,
+		},
+		headers: headers,
+		cookies: config.Cookies,
+	}, nil
+}
+
+func (p *httpProfile) apply(req *http.Request) {
+	for key, value := range p.headers {
+		if value == "" {
+			req.Header.Del(key)
+			continue
+		}
+		req.Header.Set(key, value)
+	}
+
+	host := strings.ToLower(req.URL.Hostname())
+	for _, cookie := range p.cookies {
+		if !cookieMatchesHost(cookie.Domain, host) {
+			continue
+		}
+		req.AddCookie(&http.Cookie{
+			Name:  cookie.Name,
+			Value: cookie.Value,
+		})
+	}
+}
+
+func cookieMatchesHost(domain, host string) bool {
+	domain = strings.ToLower(strings.TrimPrefix(domain, "."))
+	if domain == "" {
+		return true
+	}
+
+	return host == domain || strings.HasSuffix(host, "."+domain)
+}
+
+type httpProfileKey struct{}
+
+func WithHTTPProfile(ctx context.Context, name string) context.Context {
+	if name == "" {
+		return ctx
+	}
+	return context.WithValue(ctx, httpProfileKey{}, name)
+}
+
+func httpProfileFromContext(ctx context.Context) (string, bool) {
+	name, ok := ctx.Value(httpProfileKey{}).(string)
+	return name, ok
+}
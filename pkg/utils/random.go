@@ -2,17 +2,35 @@ package utils
 
 import (
 	"math/rand"
+	"sync"
 	"time"
 )
 
-var seededRand = rand.New(rand.NewSource(time.Now().UnixNano()))
+var (
+	seededRand   = rand.New(rand.NewSource(time.Now().UnixNano()))
+	seededRandMu sync.Mutex
+)
 
 const charset = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
 
 func GenerateRandomString(length int) string {
+	seededRandMu.Lock()
+	defer seededRandMu.Unlock()
+
 	b := make([]byte, length)
 	for i := range b {
 		b[i] = charset[seededRand.Intn(len(charset))]
 	}
 	return string(b)
 }
+
+func RandomDuration(max time.Duration) time.Duration {
+	if max <= 0 {
+		return 0
+	}
+
+	seededRandMu.Lock()
+	defer seededRandMu.Unlock()
+
+	return time.Duration(seededRand.Int63n(int64(max)))
+}
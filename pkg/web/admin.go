@@ -0,0 +1,252 @@
+package web
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"embed"
+	"encoding/hex"
+	"fmt"
+	"go-nelson/pkg"
+	"go-nelson/pkg/db"
+	"go-nelson/pkg/news"
+	"go-nelson/pkg/services"
+	"go-nelson/pkg/structures"
+	"html/template"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	adminDeliveriesLimit = 50
+	adminNewsLimit       = 30
+)
+
+//go:embed templates/admin.html
+var adminTemplates embed.FS
+
+var adminTemplate = template.Must(template.New("admin.html").Funcs(template.FuncMap{
+	"formatTime":     formatAdminTime,
+	"formatDuration": func(d time.Duration) string { return d.Round(time.Millisecond).String() },
+	"dict":           adminDict,
+}).ParseFS(adminTemplates, "templates/admin.html"))
+
+type adminPage struct {
+	Message string
+	CSRF    string
+	Sources []adminSource
+	Pending []adminDelivery
+	Failed  []adminDelivery
+	News    []adminNews
+}
+
+type adminSource struct {
+	news.SourceStatus
+	Total int64
+}
+
+type adminDelivery struct {
+	*structures.Delivery
+	ID        string
+	NewsID    string
+	NewsTitle string
+	NewsURL   string
+}
+
+type adminNews struct {
+	*structures.News
+	ID         string
+	DiscordURL string
+}
+
+func init() {
+	handle("GET /admin", adminHandler(handleAdminDashboard))
+	handle("POST /admin/sources/{name}/run", adminHandler(adminAction(handleAdminRunSource)))
+	handle("POST /admin/deliveries/{id}/retry", adminHandler(adminAction(handleAdminRetryDelivery)))
+	handle("POST /admin/news/{id}/hide", adminHandler(adminAction(handleAdminHideNews)))
+}
+
+// Используется Basic-аутентификация: имя пользователя не проверяется, только пароль
+func adminHandler(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		password := pkg.Server.Admin.Password
+		if password == "" {
+			http.NotFound(w, r)
+			return
+		}
+
+		_, provided, ok := r.BasicAuth()
+		if !ok || subtle.ConstantTimeCompare([]byte(password), []byte(provided)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Basic realm="Nelson", charset="UTF-8"`)
+			http.Error(w, "требуется авторизация", http.StatusUnauthorized)
+			return
+		}
+
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("X-Frame-Options", "DENY")
+		next(w, r)
+	}
+}
+
+// Браузер отправляет Basic-учетные данные автоматически, поэтому формы дополнительно защищены токеном
+func adminAction(action func(r *http.Request) (string, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(adminCSRFToken()), []byte(r.FormValue("csrf"))) != 1 {
+			http.Error(w, "некорректный CSRF-токен", http.StatusForbidden)
+			return
+		}
+
+		message, err := action(r)
+		if err != nil {
+			slog.Error("Admin action failed", "path", r.URL.Path, "error", err)
+			message = "Ошибка: " + err.Error()
+		}
+
+		http.Redirect(w, r, "/admin?message="+url.QueryEscape(message), http.StatusSeeOther)
+	}
+}
+
+func adminCSRFToken() string {
+	mac := hmac.New(sha256.New, []byte(pkg.Server.Admin.Password))
+	mac.Write([]byte("nelson-admin-csrf"))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func handleAdminDashboard(w http.ResponseWriter, r *http.Request) {
+	page := adminPage{
+		Message: r.URL.Query().Get("message"),
+		CSRF:    adminCSRFToken(),
+	}
+
+	newsRepo := db.NewNewsRepository()
+	deliveryRepo := db.NewDeliveryRepository()
+
+	totals := make(map[string]int64)
+	stats, err := newsRepo.ProviderStats()
+	if err != nil {
+		slog.Error("Failed to get source statistics for admin dashboard", "error", err)
+	}
+	for _, stat := range stats {
+		totals[stat.Provider] = stat.Count
+	}
+
+	for _, status := range news.SourceStatuses() {
+		page.Sources = append(page.Sources, adminSource{SourceStatus: status, Total: totals[status.Provider]})
+	}
+
+	page.Pending = loadAdminDeliveries(deliveryRepo, newsRepo, structures.DeliveryStatusPending)
+	page.Failed = loadAdminDeliveries(deliveryRepo, newsRepo, structures.DeliveryStatusFailed)
+
+	recent, err := newsRepo.FindRecent(db.NewsFilter{}, 0, adminNewsLimit)
+	if err != nil {
+		slog.Error("Failed to load news for admin dashboard", "error", err)
+	}
+	threadURLs := services.DiscordThreadURLs(recent)
+	for _, n := range recent {
+		page.News = append(page.News, adminNews{News: n, ID: n.Id.Hex(), DiscordURL: threadURLs[n.Id]})
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := adminTemplate.Execute(w, page); err != nil {
+		slog.Error("Failed to render admin dashboard", "error", err)
+	}
+}
+
+func loadAdminDeliveries(deliveryRepo *db.DeliveryRepository, newsRepo *db.NewsRepository, status string) []adminDelivery {
+	deliveries, err := deliveryRepo.FindByStatus(status, adminDeliveriesLimit)
+	if err != nil {
+		slog.Error("Failed to load delivery queue for admin dashboard", "status", status, "error", err)
+		return nil
+	}
+
+	ids := make([]primitive.ObjectID, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		ids = append(ids, delivery.NewsID)
+	}
+
+	newsByID := make(map[primitive.ObjectID]*structures.News)
+	found, err := newsRepo.FindByIDs(ids)
+	if err != nil {
+		slog.Error("Failed to load delivery queue news", "error", err)
+	}
+	for _, n := range found {
+		newsByID[n.Id] = n
+	}
+
+	result := make([]adminDelivery, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		item := adminDelivery{Delivery: delivery, ID: delivery.Id.Hex(), NewsID: delivery.NewsID.Hex()}
+		if n, ok := newsByID[delivery.NewsID]; ok {
+			item.NewsTitle = n.Title
+			item.NewsURL = n.URL
+		}
+		result = append(result, item)
+	}
+
+	return result
+}
+
+func handleAdminRunSource(r *http.Request) (string, error) {
+	name := r.PathValue("name")
+	if err := news.TriggerSource(name); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("Источник %s поставлен в очередь на запуск", name), nil
+}
+
+func handleAdminRetryDelivery(r *http.Request) (string, error) {
+	id, err := primitive.ObjectIDFromHex(r.PathValue("id"))
+	if err != nil {
+		return "", fmt.Errorf("некорректный ID доставки")
+	}
+
+	if err := db.NewDeliveryRepository().Retry(id); err != nil {
+		return "", fmt.Errorf("не удалось вернуть доставку в очередь: %w", err)
+	}
+	return "Доставка возвращена в очередь", nil
+}
+
+// Скрытая новость пропадает из лент, API и команд ботов, а ее неотправленные публикации отменяются
+func handleAdminHideNews(r *http.Request) (string, error) {
+	id, err := primitive.ObjectIDFromHex(r.PathValue("id"))
+	if err != nil {
+		return "", fmt.Errorf("некорректный ID новости")
+	}
+
+	if err := db.NewNewsRepository().Hide(id); err != nil {
+		return "", fmt.Errorf("не удалось скрыть новость: %w", err)
+	}
+
+	if err := db.NewDeliveryRepository().CancelPending(id, "новость скрыта в панели управления"); err != nil {
+		slog.Error("Failed to cancel delivery of hidden news", "news_id", id.Hex(), "error", err)
+	}
+
+	return "Новость скрыта", nil
+}
+
+func formatAdminTime(t time.Time) string {
+	if t.IsZero() {
+		return "—"
+	}
+	return t.Local().Format("02.01.2006 15:04:05")
+}
+
+func adminDict(values ...any) (map[string]any, error) {
+	if len(values)%2 != 0 {
+		return nil, fmt.Errorf("dict ожидает пары ключ-значение")
+	}
+
+	result := make(map[string]any, len(values)/2)
+	for i := 0; i < len(values); i += 2 {
+		key, ok := values[i].(string)
+		if !ok {
+			return nil, fmt.Errorf("ключ dict должен быть строкой")
+		}
+		result[key] = values[i+1]
+	}
+	return result, nil
+}
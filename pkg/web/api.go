@@ -0,0 +1,404 @@
+package web
+
+import (
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"go-nelson/pkg"
+	"go-nelson/pkg/db"
+	"go-nelson/pkg/services"
+	"go-nelson/pkg/structures"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/qiniu/qmgo"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+const (
+	defaultAPIRateLimit = 60
+	defaultAPIPageSize  = 20
+	maxAPIPageSize      = 100
+)
+
+var apiLimiter = newRateLimiter()
+
+type apiNews struct {
+	ID          string     `json:"id"`
+	Provider    string     `json:"provider"`
+	Title       string     `json:"title"`
+	Description string     `json:"description"`
+	URL         string     `json:"url"`
+	Tags        []string   `json:"tags"`
+	Images      []string   `json:"images"`
+	Language    string     `json:"language,omitempty"`
+	PublishedAt *time.Time `json:"published_at,omitempty"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   time.Time  `json:"updated_at"`
+}
+
+type apiList[T any] struct {
+	Data       []T    `json:"data"`
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+type apiSource struct {
+	Name     string `json:"name"`
+	Provider string `json:"provider"`
+}
+
+type apiProviderCount struct {
+	Provider string     `json:"provider"`
+	Count    int64      `json:"count"`
+	LastAt   *time.Time `json:"last_at,omitempty"`
+}
+
+type apiError struct {
+	Error string `json:"error"`
+}
+
+func init() {
+	handle("GET /api/v1/news", apiHandler(handleAPINewsList))
+	handle("GET /api/v1/news/{id}", apiHandler(handleAPINewsByID))
+	handle("GET /api/v1/search", apiHandler(handleAPISearch))
+	handle("GET /api/v1/providers", apiHandler(handleAPIProviders))
+	handle("GET /api/v1/sources", apiHandler(handleAPISources))
+}
+
+// Ключ передается в заголовке Authorization: Bearer <ключ> или X-API-Key
+func apiHandler(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if len(pkg.Server.API.Keys) == 0 {
+			writeAPIError(w, http.StatusNotFound, "API не настроен")
+			return
+		}
+
+		key, ok := findAPIKey(r)
+		if !ok {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeAPIError(w, http.StatusUnauthorized, "неверный или отсутствующий API-ключ")
+			return
+		}
+
+		limit := key.RateLimit
+		if limit <= 0 {
+			limit = defaultAPIRateLimit
+		}
+
+		allowed, remaining, wait := apiLimiter.allow(key.Name+":"+key.Key, limit)
+		w.Header().Set("X-RateLimit-Limit", strconv.Itoa(limit))
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+		if !allowed {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+			writeAPIError(w, http.StatusTooManyRequests, "превышен лимит запросов")
+			return
+		}
+
+		next(w, r)
+	}
+}
+
+func findAPIKey(r *http.Request) (structures.APIKeyConfigStruct, bool) {
+	provided := r.Header.Get("X-API-Key")
+	if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		provided = strings.TrimSpace(bearer)
+	}
+	if provided == "" {
+		return structures.APIKeyConfigStruct{}, false
+	}
+
+	for _, key := range pkg.Server.API.Keys {
+		if key.Key != "" && subtle.ConstantTimeCompare([]byte(key.Key), []byte(provided)) == 1 {
+			return key, true
+		}
+	}
+	return structures.APIKeyConfigStruct{}, false
+}
+
+func handleAPINewsList(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseAPIFilter(r)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	limit, err := parseAPILimit(r)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	var cursor *db.NewsCursor
+	if value := r.URL.Query().Get("cursor"); value != "" {
+		cursor, err = decodeNewsCursor(value)
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, "некорректный cursor")
+			return
+		}
+	}
+
+	news, err := db.NewNewsRepository().FindAfter(filter, cursor, int64(limit))
+	if err != nil {
+		slog.Error("Failed to load news for API", "error", err)
+		writeAPIError(w, http.StatusInternalServerError, "не удалось загрузить новости")
+		return
+	}
+
+	result := apiList[apiNews]{Data: makeAPINewsList(news)}
+	if len(news) == limit {
+		last := news[len(news)-1]
+		result.NextCursor = encodeNewsCursor(db.NewsCursor{CreateAt: last.CreateAt, ID: last.Id})
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+func handleAPINewsByID(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if !primitive.IsValidObjectID(id) {
+		writeAPIError(w, http.StatusNotFound, "новость не найдена")
+		return
+	}
+
+	news, err := db.NewNewsRepository().FindByID(id)
+	if err != nil || !news.RetractedAt.IsZero() || !news.HiddenAt.IsZero() {
+		if err == nil || qmgo.IsErrNoDocuments(err) {
+			writeAPIError(w, http.StatusNotFound, "новость не найдена")
+			return
+		}
+		slog.Error("Failed to load news item for API", "news_id", id, "error", err)
+		writeAPIError(w, http.StatusInternalServerError, "не удалось загрузить новость")
+		return
+	}
+
+	writeJSON(w, http.StatusOK, makeAPINews(news))
+}
+
+// Результаты поиска упорядочены по релевантности, поэтому курсор хранит смещение
+func handleAPISearch(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseAPIFilter(r)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	filter.Text = strings.TrimSpace(r.URL.Query().Get("q"))
+	if filter.Text == "" {
+		writeAPIError(w, http.StatusBadRequest, "не указан параметр q")
+		return
+	}
+
+	limit, err := parseAPILimit(r)
+	if err != nil {
+		writeAPIError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	offset := 0
+	if value := r.URL.Query().Get("cursor"); value != "" {
+		offset, err = decodeOffsetCursor(value)
+		if err != nil {
+			writeAPIError(w, http.StatusBadRequest, "некорректный cursor")
+			return
+		}
+	}
+
+	news, err := db.NewNewsRepository().Search(filter, int64(offset), int64(limit))
+	if err != nil {
+		slog.Error("Failed to search news for API", "error", err)
+		writeAPIError(w, http.StatusInternalServerError, "не удалось выполнить поиск")
+		return
+	}
+
+	result := apiList[apiNews]{Data: makeAPINewsList(news)}
+	if len(news) == limit {
+		result.NextCursor = encodeOffsetCursor(offset + limit)
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+func handleAPIProviders(w http.ResponseWriter, _ *http.Request) {
+	stats, err := db.NewNewsRepository().ProviderStats()
+	if err != nil {
+		slog.Error("Failed to get source statistics for API", "error", err)
+		writeAPIError(w, http.StatusInternalServerError, "не удалось загрузить статистику")
+		return
+	}
+
+	result := apiList[apiProviderCount]{Data: make([]apiProviderCount, 0, len(stats))}
+	for _, stat := range stats {
+		result.Data = append(result.Data, apiProviderCount{
+			Provider: stat.Provider,
+			Count:    stat.Count,
+			LastAt:   optionalTime(stat.LastAt),
+		})
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+func handleAPISources(w http.ResponseWriter, _ *http.Request) {
+	sources := services.RegisteredSources()
+
+	result := apiList[apiSource]{Data: make([]apiSource, 0, len(sources))}
+	for _, source := range sources {
+		result.Data = append(result.Data, apiSource{Name: source.Name, Provider: source.Provider})
+	}
+
+	writeJSON(w, http.StatusOK, result)
+}
+
+func parseAPIFilter(r *http.Request) (db.NewsFilter, error) {
+	query := r.URL.Query()
+	var filter db.NewsFilter
+
+	if provider := strings.TrimSpace(query.Get("provider")); provider != "" {
+		resolved, ok := services.FindProvider(provider)
+		if !ok {
+			return filter, fmt.Errorf("источник %s не найден", provider)
+		}
+		filter.Provider = resolved
+	}
+
+	filter.Tag = strings.TrimSpace(query.Get("tag"))
+
+	var err error
+	if filter.From, err = parseAPIDate(query.Get("from"), false); err != nil {
+		return filter, fmt.Errorf("некорректный from: %w", err)
+	}
+	if filter.To, err = parseAPIDate(query.Get("to"), true); err != nil {
+		return filter, fmt.Errorf("некорректный to: %w", err)
+	}
+
+	return filter, nil
+}
+
+// Дата без времени в параметре to включает весь указанный день
+func parseAPIDate(value string, endOfDay bool) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	if parsed, err := time.Parse(time.RFC3339, value); err == nil {
+		return parsed, nil
+	}
+
+	parsed, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("ожидается RFC 3339 или ГГГГ-ММ-ДД")
+	}
+	if endOfDay {
+		parsed = parsed.AddDate(0, 0, 1)
+	}
+	return parsed, nil
+}
+
+func parseAPILimit(r *http.Request) (int, error) {
+	value := r.URL.Query().Get("limit")
+	if value == "" {
+		return defaultAPIPageSize, nil
+	}
+
+	limit, err := strconv.Atoi(value)
+	if err != nil || limit <= 0 {
+		return 0, fmt.Errorf("некорректный limit")
+	}
+	return min(limit, maxAPIPageSize), nil
+}
+
+func encodeNewsCursor(cursor db.NewsCursor) string {
+	raw := strconv.FormatInt(cursor.CreateAt.UnixMilli(), 10) + "_" + cursor.ID.Hex()
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func decodeNewsCursor(value string) (*db.NewsCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil, err
+	}
+
+	millis, hexID, found := strings.Cut(string(raw), "_")
+	if !found {
+		return nil, fmt.Errorf("неизвестный формат курсора")
+	}
+
+	createAt, err := strconv.ParseInt(millis, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	id, err := primitive.ObjectIDFromHex(hexID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &db.NewsCursor{CreateAt: time.UnixMilli(createAt), ID: id}, nil
+}
+
+func encodeOffsetCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte("o" + strconv.Itoa(offset)))
+}
+
+func decodeOffsetCursor(value string) (int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return 0, err
+	}
+
+	offset, err := strconv.Atoi(strings.TrimPrefix(string(raw), "o"))
+	if err != nil || offset < 0 {
+		return 0, fmt.Errorf("неизвестный формат курсора")
+	}
+	return offset, nil
+}
+
+func makeAPINewsList(news []*structures.News) []apiNews {
+	result := make([]apiNews, 0, len(news))
+	for _, n := range news {
+		result = append(result, makeAPINews(n))
+	}
+	return result
+}
+
+func makeAPINews(news *structures.News) apiNews {
+	return apiNews{
+		ID:          news.Id.Hex(),
+		Provider:    news.Provider,
+		Title:       news.Title,
+		Description: news.Description,
+		URL:         news.URL,
+		Tags:        append([]string{}, news.Tags...),
+		Images:      append([]string{}, news.Images...),
+		Language:    news.Language,
+		PublishedAt: optionalTime(news.PublishedAt),
+		ExpiresAt:   optionalTime(news.ExpiresAt),
+		CreatedAt:   news.CreateAt,
+		UpdatedAt:   news.UpdateAt,
+	}
+}
+
+func optionalTime(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
+
+func writeJSON(w http.ResponseWriter, status int, value any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(value); err != nil {
+		slog.Error("Failed to write API response", "error", err)
+	}
+}
+
+func writeAPIError(w http.ResponseWriter, status int, message string) {
+	writeJSON(w, status, apiError{Error: message})
+}
@@ -0,0 +1,364 @@
+package web
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"go-nelson/pkg"
+	"go-nelson/pkg/db"
+	"go-nelson/pkg/services"
+	"go-nelson/pkg/structures"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultFeedTitle    = "Nelson"
+	defaultFeedLimit    = 50
+	defaultFeedMaxLimit = 200
+	defaultFeedMaxAge   = 5 * time.Minute
+)
+
+type feedRequest struct {
+	title    string
+	link     string
+	selfURL  string
+	filter   db.NewsFilter
+	news     []*structures.News
+	modified time.Time
+}
+
+type rssDocument struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Atom    string     `xml:"xmlns:atom,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate,omitempty"`
+	Self          atomLink  `xml:"atom:link"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string   `xml:"title"`
+	Link        string   `xml:"link"`
+	Description string   `xml:"description"`
+	GUID        rssGUID  `xml:"guid"`
+	PubDate     string   `xml:"pubDate"`
+	Author      string   `xml:"author,omitempty"`
+	Categories  []string `xml:"category"`
+}
+
+type rssGUID struct {
+	IsPermaLink string `xml:"isPermaLink,attr"`
+	Value       string `xml:",chardata"`
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"feed"`
+	Xmlns   string      `xml:"xmlns,attr"`
+	ID      string      `xml:"id"`
+	Title   string      `xml:"title"`
+	Updated string      `xml:"updated"`
+	Links   []atomLink  `xml:"link"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr,omitempty"`
+	Type string `xml:"type,attr,omitempty"`
+}
+
+type atomEntry struct {
+	ID         string         `xml:"id"`
+	Title      string         `xml:"title"`
+	Updated    string         `xml:"updated"`
+	Published  string         `xml:"published"`
+	Link       atomLink       `xml:"link"`
+	Author     atomAuthor     `xml:"author"`
+	Summary    atomText       `xml:"summary"`
+	Categories []atomCategory `xml:"category"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomText struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+type atomCategory struct {
+	Term string `xml:"term,attr"`
+}
+
+type jsonFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageURL string         `json:"home_page_url"`
+	FeedURL     string         `json:"feed_url"`
+	Items       []jsonFeedItem `json:"items"`
+}
+
+type jsonFeedItem struct {
+	ID            string           `json:"id"`
+	URL           string           `json:"url"`
+	Title         string           `json:"title"`
+	ContentText   string           `json:"content_text"`
+	Image         string           `json:"image,omitempty"`
+	DatePublished string           `json:"date_published"`
+	DateModified  string           `json:"date_modified,omitempty"`
+	Tags          []string         `json:"tags,omitempty"`
+	Authors       []jsonFeedAuthor `json:"authors"`
+	Language      string           `json:"language,omitempty"`
+}
+
+type jsonFeedAuthor struct {
+	Name string `json:"name"`
+}
+
+func init() {
+	handle("GET /feed.rss", feedHandler("application/rss+xml; charset=utf-8", renderRSS))
+	handle("GET /feed.atom", feedHandler("application/atom+xml; charset=utf-8", renderAtom))
+	handle("GET /feed.json", feedHandler("application/feed+json; charset=utf-8", renderJSONFeed))
+}
+
+func feedHandler(contentType string, render func(feed *feedRequest) ([]byte, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		feed, status, err := loadFeed(r)
+		if err != nil {
+			if status == http.StatusInternalServerError {
+				slog.Error("Failed to load news for feed", "path", r.URL.Path, "error", err)
+			}
+			http.Error(w, err.Error(), status)
+			return
+		}
+
+		body, err := render(feed)
+		if err != nil {
+			slog.Error("Failed to render feed", "path", r.URL.Path, "error", err)
+			http.Error(w, "ошибка при формировании ленты", http.StatusInternalServerError)
+			return
+		}
+
+		sum := sha256.Sum256(body)
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(feedMaxAge().Seconds())))
+		w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:16])+`"`)
+
+		// ServeContent сам отвечает 304 на If-None-Match и If-Modified-Since
+		http.ServeContent(w, r, "", feed.modified, bytes.NewReader(body))
+	}
+}
+
+func loadFeed(r *http.Request) (*feedRequest, int, error) {
+	query := r.URL.Query()
+	base := baseURL(r)
+
+	feed := &feedRequest{
+		title:   feedTitle(),
+		link:    base,
+		selfURL: base + r.URL.RequestURI(),
+	}
+
+	if provider := strings.TrimSpace(query.Get("provider")); provider != "" {
+		resolved, ok := services.FindProvider(provider)
+		if !ok {
+			return nil, http.StatusNotFound, fmt.Errorf("источник %s не найден", provider)
+		}
+		feed.filter.Provider = resolved
+		feed.title += " — " + resolved
+	}
+
+	if tag := strings.TrimSpace(query.Get("tag")); tag != "" {
+		feed.filter.Tag = tag
+		feed.title += " — #" + tag
+	}
+
+	if search := strings.TrimSpace(query.Get("q")); search != "" {
+		feed.filter.Query = search
+		feed.title += " — " + search
+	}
+
+	limit := feedLimit()
+	if value := query.Get("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			return nil, http.StatusBadRequest, fmt.Errorf("некорректный limit")
+		}
+		limit = min(parsed, feedMaxLimit())
+	}
+
+	news, err := db.NewNewsRepository().FindRecent(feed.filter, 0, int64(limit))
+	if err != nil {
+		return nil, http.StatusInternalServerError, fmt.Errorf("не удалось загрузить новости")
+	}
+	feed.news = news
+
+	for _, n := range news {
+		if modified := newsModified(n); modified.After(feed.modified) {
+			feed.modified = modified
+		}
+	}
+
+	return feed, http.StatusOK, nil
+}
+
+func renderRSS(feed *feedRequest) ([]byte, error) {
+	channel := rssChannel{
+		Title:       feed.title,
+		Link:        feed.link,
+		Description: feed.title,
+		Self:        atomLink{Href: feed.selfURL, Rel: "self", Type: "application/rss+xml"},
+		Items:       make([]rssItem, 0, len(feed.news)),
+	}
+	if !feed.modified.IsZero() {
+		channel.LastBuildDate = feed.modified.UTC().Format(time.RFC1123Z)
+	}
+
+	for _, n := range feed.news {
+		channel.Items = append(channel.Items, rssItem{
+			Title:       n.Title,
+			Link:        n.URL,
+			Description: n.Description,
+			GUID:        rssGUID{IsPermaLink: "false", Value: n.Id.Hex()},
+			PubDate:     n.CreateAt.UTC().Format(time.RFC1123Z),
+			Categories:  append([]string{n.Provider}, n.Tags...),
+		})
+	}
+
+	return marshalXML(rssDocument{Version: "2.0", Atom: "http://www.w3.org/2005/Atom", Channel: channel})
+}
+
+func renderAtom(feed *feedRequest) ([]byte, error) {
+	updated := feed.modified
+	if updated.IsZero() {
+		updated = time.Now()
+	}
+
+	document := atomFeed{
+		Xmlns:   "http://www.w3.org/2005/Atom",
+		ID:      feed.selfURL,
+		Title:   feed.title,
+		Updated: updated.UTC().Format(time.RFC3339),
+		Links: []atomLink{
+			{Href: feed.selfURL, Rel: "self", Type: "application/atom+xml"},
+			{Href: feed.link, Rel: "alternate"},
+		},
+		Entries: make([]atomEntry, 0, len(feed.news)),
+	}
+
+	for _, n := range feed.news {
+		entry := atomEntry{
+			ID:        "urn:nelson:news:" + n.Id.Hex(),
+			Title:     n.Title,
+			Updated:   newsModified(n).UTC().Format(time.RFC3339),
+			Published: n.CreateAt.UTC().Format(time.RFC3339),
+			Link:      atomLink{Href: n.URL, Rel: "alternate"},
+			Author:    atomAuthor{Name: n.Provider},
+			Summary:   atomText{Type: "text", Value: n.Description},
+		}
+		for _, tag := range n.Tags {
+			entry.Categories = append(entry.Categories, atomCategory{Term: tag})
+		}
+
+		document.Entries = append(document.Entries, entry)
+	}
+
+	return marshalXML(document)
+}
+
+func renderJSONFeed(feed *feedRequest) ([]byte, error) {
+	document := jsonFeed{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       feed.title,
+		HomePageURL: feed.link,
+		FeedURL:     feed.selfURL,
+		Items:       make([]jsonFeedItem, 0, len(feed.news)),
+	}
+
+	for _, n := range feed.news {
+		item := jsonFeedItem{
+			ID:            n.Id.Hex(),
+			URL:           n.URL,
+			Title:         n.Title,
+			ContentText:   n.Description,
+			DatePublished: n.CreateAt.UTC().Format(time.RFC3339),
+			Tags:          n.Tags,
+			Authors:       []jsonFeedAuthor{{Name: n.Provider}},
+			Language:      n.Language,
+		}
+		if !n.UpdateAt.IsZero() {
+			item.DateModified = n.UpdateAt.UTC().Format(time.RFC3339)
+		}
+		if len(n.Images) > 0 {
+			item.Image = n.Images[0]
+		}
+
+		document.Items = append(document.Items, item)
+	}
+
+	return json.Marshal(document)
+}
+
+func marshalXML(document any) ([]byte, error) {
+	body, err := xml.Marshal(document)
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), body...), nil
+}
+
+func newsModified(news *structures.News) time.Time {
+	if news.UpdateAt.After(news.CreateAt) {
+		return news.UpdateAt
+	}
+	return news.CreateAt
+}
+
+func feedTitle() string {
+	if pkg.Server.Feed.Title != "" {
+		return pkg.Server.Feed.Title
+	}
+	return defaultFeedTitle
+}
+
+func feedLimit() int {
+	if pkg.Server.Feed.Limit > 0 {
+		return min(pkg.Server.Feed.Limit, feedMaxLimit())
+	}
+	return min(defaultFeedLimit, feedMaxLimit())
+}
+
+func feedMaxLimit() int {
+	if pkg.Server.Feed.MaxLimit > 0 {
+		return pkg.Server.Feed.MaxLimit
+	}
+	return defaultFeedMaxLimit
+}
+
+func feedMaxAge() time.Duration {
+	if pkg.Server.Feed.MaxAge == "" {
+		return defaultFeedMaxAge
+	}
+
+	parsed, err := time.ParseDuration(pkg.Server.Feed.MaxAge)
+	if err != nil || parsed < 0 {
+		slog.Warn("Invalid feed max_age, using default", "value", pkg.Server.Feed.MaxAge, "default", defaultFeedMaxAge)
+		return defaultFeedMaxAge
+	}
+	return parsed
+}
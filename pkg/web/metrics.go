@@ -0,0 +1,51 @@
+package web
+
+import (
+	"go-nelson/pkg/db"
+	"go-nelson/pkg/metrics"
+	"go-nelson/pkg/structures"
+	"log/slog"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Глубина очереди считается при каждом сборе метрик, чтобы не расходиться с базой после перезапуска
+type deliveryQueueCollector struct {
+	depth  *prometheus.Desc
+	oldest *prometheus.Desc
+}
+
+func init() {
+	metrics.Registry.MustRegister(&deliveryQueueCollector{
+		depth: prometheus.NewDesc("nelson_delivery_queue_depth",
+			"Доставки в очереди по назначению и статусу: pending, failed",
+			[]string{"destination", "status"}, nil),
+		oldest: prometheus.NewDesc("nelson_delivery_queue_oldest_seconds",
+			"Возраст самой давно не обновлявшейся доставки в очереди",
+			[]string{"destination", "status"}, nil),
+	})
+
+	handle("GET /metrics", promhttp.HandlerFor(metrics.Registry, promhttp.HandlerOpts{}).ServeHTTP)
+}
+
+func (c *deliveryQueueCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.depth
+	ch <- c.oldest
+}
+
+func (c *deliveryQueueCollector) Collect(ch chan<- prometheus.Metric) {
+	stats, err := db.NewDeliveryRepository().QueueStats()
+	if err != nil {
+		slog.Error("Failed to get delivery queue stats for metrics", "error", err)
+		return
+	}
+
+	for _, stat := range stats {
+		ch <- prometheus.MustNewConstMetric(c.depth, prometheus.GaugeValue, float64(stat.Count), stat.Key.Destination, stat.Key.Status)
+		if stat.Key.Status == structures.DeliveryStatusPending && !stat.Oldest.IsZero() {
+			ch <- prometheus.MustNewConstMetric(c.oldest, prometheus.GaugeValue, time.Since(stat.Oldest).Seconds(), stat.Key.Destination, stat.Key.Status)
+		}
+	}
+}
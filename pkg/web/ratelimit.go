@@ -0,0 +1,49 @@
+package web
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// Корзина токенов на ключ: полный запас равен лимиту в минуту и восполняется равномерно
+type rateLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+}
+
+type tokenBucket struct {
+	tokens  float64
+	limit   float64
+	updated time.Time
+}
+
+func newRateLimiter() *rateLimiter {
+	return &rateLimiter{buckets: make(map[string]*tokenBucket)}
+}
+
+// allow возвращает остаток запросов или время до появления следующего токена
+func (l *rateLimiter) allow(key string, perMinute int) (bool, int, time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	limit := float64(perMinute)
+
+	bucket, ok := l.buckets[key]
+	if !ok || bucket.limit != limit {
+		bucket = &tokenBucket{tokens: limit, limit: limit, updated: now}
+		l.buckets[key] = bucket
+	}
+
+	bucket.tokens = math.Min(limit, bucket.tokens+now.Sub(bucket.updated).Minutes()*limit)
+	bucket.updated = now
+
+	if bucket.tokens < 1 {
+		wait := time.Duration((1 - bucket.tokens) / limit * float64(time.Minute))
+		return false, 0, wait
+	}
+
+	bucket.tokens--
+	return true, int(bucket.tokens), 0
+}
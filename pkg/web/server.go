@@ -0,0 +1,74 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"go-nelson/pkg"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const defaultServerAddress = ":8080"
+
+var (
+	mux    = http.NewServeMux()
+	server *http.Server
+)
+
+// Обработчики регистрируются из init() файлов пакета, как команды ботов в services
+func handle(pattern string, handler http.HandlerFunc) {
+	mux.HandleFunc(pattern, handler)
+}
+
+func Start() {
+	if !pkg.Server.Enabled {
+		return
+	}
+
+	address := pkg.Server.Address
+	if address == "" {
+		address = defaultServerAddress
+	}
+
+	server = &http.Server{
+		Addr:              address,
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+		WriteTimeout:      30 * time.Second,
+	}
+
+	go func() {
+		slog.Info("Starting HTTP server", "address", address)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			slog.Error("HTTP server failed", "error", err)
+		}
+	}()
+}
+
+func Close() {
+	if server == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	if err := server.Shutdown(ctx); err != nil {
+		slog.Error("Failed to stop HTTP server", "error", err)
+	}
+}
+
+// Без base_url адрес собирается из запроса, что подходит только для работы без обратного прокси
+func baseURL(r *http.Request) string {
+	if pkg.Server.BaseURL != "" {
+		return strings.TrimRight(pkg.Server.BaseURL, "/")
+	}
+
+	scheme := "http"
+	if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}